@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// maxMoves is how many moves a Pokemon brings into battle.
+const maxMoves = 4
+
+func commandBattle(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Battle != nil {
+		return reportFailure(errors.New("you are already battling " + config.Battle.Wild.Name + ", fight, catch it or run"))
+	}
+	if config.Encounter == nil {
+		return reportFailure(errors.New("there is no wild pokemon to battle, use encounter to find one"))
+	}
+	caught, err := config.Storage.Find(commandWords[1])
+	if err != nil {
+		return reportFailure(err)
+	}
+	if !config.Storage.InParty(caught.ID) {
+		return reportFailure(errors.New(caught.Name + " is in a PC box, withdraw it to battle"))
+	}
+
+	wildPokemon, err := config.Client.GetPokemon(ctx, config.Encounter.Pokemon)
+	if err != nil {
+		return reportAPIError(err, "there is no pokemon called " + config.Encounter.Pokemon)
+	}
+	playerPokemon, err := config.Client.GetPokemon(ctx, caught.Name)
+	if err != nil {
+		return reportAPIError(err, "there is no pokemon called " + caught.Name)
+	}
+	player, err := newCombatant(ctx, config.Client, playerPokemon, caught.Level, caught.ActualStats(playerPokemon))
+	if err != nil {
+		return reportAPIError(err, "there is no move data for " + caught.Name)
+	}
+	wild, err := newCombatant(ctx, config.Client, wildPokemon, config.Encounter.Level, wildStats(wildPokemon, *config.Encounter))
+	if err != nil {
+		return reportAPIError(err, "there is no move data for " + wildPokemon.Name)
+	}
+	chart, err := typeChart(ctx, config.Client, player, wild)
+	if err != nil {
+		return reportAPIError(err, "there is no type chart data")
+	}
+
+	config.Battle = battle.New(player, wild, chart, config.Rand)
+	config.BattlerID = caught.ID
+	fmt.Println("Go, " + player.Name + "! (level " + strconv.Itoa(player.Level) + ", " + hpText(player) + ")")
+	fmt.Println("The wild " + wild.Name + " has " + hpText(wild))
+	fmt.Println("Moves: " + strings.Join(player.MoveNames(), ", "))
+	return nil
+}
+
+func commandFight(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Battle == nil {
+		return reportFailure(errors.New("you are not in a battle, use battle <pokemon> to start one"))
+	}
+
+	b := config.Battle
+	hits, err := b.Turn(commandWords[1])
+	if errors.Is(err, battle.ErrUnknownMove) {
+		return reportFailure(errors.New(b.Player.Name + " doesn't know " + commandWords[1] + ", try one of: " + strings.Join(b.Player.MoveNames(), ", ")))
+	}
+	if err != nil {
+		return err
+	}
+
+	if config.Output.structured() {
+		records := make([]Record, 0, len(hits))
+		for _, hit := range hits {
+			records = append(records, Record{
+				{"turn", b.Turns},
+				{"attacker", hit.Attacker},
+				{"move", hit.Move},
+				{"missed", hit.Missed},
+				{"damage", hit.Damage},
+				{"critical", hit.Critical},
+				{"effectiveness", strconv.FormatFloat(hit.Effectiveness, 'g', -1, 64)},
+				{"defender_hp", hit.DefenderHP},
+			})
+		}
+		if err := printRecords(os.Stdout, config.Output, records); err != nil {
+			return err
+		}
+	} else {
+		for _, hit := range hits {
+			printHit(hit)
+		}
+	}
+
+	switch {
+	case b.Wild.Fainted():
+		if !config.Output.structured() {
+			fmt.Println("You won the battle!")
+		}
+		err := rewardWinner(ctx, config, pokedex, b)
+		config.Battle = nil
+		config.Encounter = nil
+		return err
+	case b.Player.Fainted():
+		if !config.Output.structured() {
+			fmt.Println("You lost the battle, the wild " + b.Wild.Name + " got away")
+		}
+		config.Battle = nil
+		config.Encounter = nil
+	}
+	return nil
+}
+
+func commandRun(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 1 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Battle == nil && config.Encounter == nil {
+		return reportFailure(errors.New("there is nothing to run from"))
+	}
+	config.Battle = nil
+	config.Encounter = nil
+	fmt.Println("Got away safely!")
+	return nil
+}
+
+// rewardWinner gives the Pokemon that won the battle experience and
+// effort values for the wild one it defeated, and evolves it when the
+// level it grew to is enough.
+func rewardWinner(ctx context.Context, config *Config, pokedex *Pokedex, b *battle.Battle) error {
+	winner := config.Storage.Get(config.BattlerID)
+	if winner == nil {
+		return nil
+	}
+	defeated, err := config.Client.GetPokemon(ctx, b.Wild.Name)
+	if err != nil {
+		return reportAPIError(err, "there is no pokemon called " + b.Wild.Name)
+	}
+	gained, before := winner.defeat(defeated, b.Wild.Level)
+	if !config.Output.structured() {
+		fmt.Println(winner.Name + " gained " + strconv.Itoa(gained) + " experience")
+		if winner.Level > before {
+			fmt.Println(winner.Name + " grew to level " + strconv.Itoa(winner.Level) + "!")
+		}
+	}
+	if winner.Level == before {
+		return nil
+	}
+	trigger := evolutionTrigger{name: triggerLevelUp, now: config.now()}
+	if _, err := tryEvolve(ctx, config, pokedex, winner, trigger); err != nil {
+		return reportAPIError(err, "there is no evolution data for " + winner.Name)
+	}
+	return nil
+}
+
+func printHit(hit battle.Hit) {
+	fmt.Println(hit.Attacker + " used " + hit.Move + "!")
+	switch {
+	case hit.Missed:
+		fmt.Println("...but it missed!")
+		return
+	case hit.Effectiveness == 0:
+		fmt.Println("It doesn't affect " + hit.Defender + "...")
+		return
+	case hit.Damage == 0:
+		return // a status move, its effect isn't simulated
+	}
+	if hit.Critical {
+		fmt.Println("A critical hit!")
+	}
+	if hit.Effectiveness > 1 {
+		fmt.Println("It's super effective!")
+	} else if hit.Effectiveness < 1 {
+		fmt.Println("It's not very effective...")
+	}
+	fmt.Println(hit.Defender + " has " + strconv.Itoa(hit.DefenderHP) + " HP left")
+	if hit.Fainted {
+		fmt.Println(hit.Defender + " fainted!")
+	}
+}
+
+func hpText(p *battle.Pokemon) string {
+	return strconv.Itoa(p.HP) + "/" + strconv.Itoa(p.Stats.HP) + " HP"
+}
+
+// newCombatant builds the battle side of pokemon at level with stats,
+// fetching the moves it knows.
+func newCombatant(ctx context.Context, client *pokeapi.Client, pokemon pokeapi.Pokemon, level int, stats battle.Stats) (*battle.Pokemon, error) {
+	var moves []battle.Move
+	for _, name := range learnedMoves(pokemon, level) {
+		move, err := client.GetMove(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, battleMove(move))
+	}
+	types := make([]string, len(pokemon.Types))
+	for i, item := range pokemon.Types {
+		types[i] = item.Type.Name
+	}
+	return battle.NewPokemon(pokemon.Name, level, types, stats, moves), nil
+}
+
+// learnedMoves returns the last maxMoves moves pokemon learns by levelling
+// up to level, in the order they're learned. A move's level is the lowest
+// one it's learned at in any version group.
+func learnedMoves(pokemon pokeapi.Pokemon, level int) []string {
+	learnedAt := make(map[string]int)
+	for _, item := range pokemon.Moves {
+		for _, detail := range item.VersionGroupDetails {
+			if detail.MoveLearnMethod.Name != "level-up" || detail.LevelLearnedAt > level {
+				continue
+			}
+			if known, ok := learnedAt[item.Move.Name]; !ok || detail.LevelLearnedAt < known {
+				learnedAt[item.Move.Name] = detail.LevelLearnedAt
+			}
+		}
+	}
+
+	names := make([]string, 0, len(learnedAt))
+	for name := range learnedAt {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if learnedAt[names[i]] != learnedAt[names[j]] {
+			return learnedAt[names[i]] < learnedAt[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names[max(0, len(names)-maxMoves):]
+}
+
+func battleMove(move pokeapi.Move) battle.Move {
+	m := battle.Move{
+		Name:     move.Name,
+		Type:     move.Type.Name,
+		Category: battle.Category(move.DamageClass.Name),
+		Priority: move.Priority,
+	}
+	if move.Power != nil {
+		m.Power = *move.Power
+	}
+	if move.Accuracy != nil {
+		m.Accuracy = *move.Accuracy
+	}
+	if move.Meta != nil {
+		m.CritRate = move.Meta.CritRate
+	}
+	return m
+}
+
+// typeChart fetches the damage relations of every type the combatants'
+// moves can be.
+func typeChart(ctx context.Context, client *pokeapi.Client, combatants ...*battle.Pokemon) (battle.TypeChart, error) {
+	chart := make(battle.TypeChart)
+	for _, combatant := range combatants {
+		for _, move := range combatant.Moves {
+			if _, ok := chart[move.Type]; ok || move.Type == "" {
+				continue
+			}
+			pokemonType, err := client.GetType(ctx, move.Type)
+			if err != nil {
+				return nil, err
+			}
+			multipliers := make(map[string]float64)
+			relations := pokemonType.DamageRelations
+			for _, defending := range relations.DoubleDamageTo {
+				multipliers[defending.Name] = 2
+			}
+			for _, defending := range relations.HalfDamageTo {
+				multipliers[defending.Name] = 0.5
+			}
+			for _, defending := range relations.NoDamageTo {
+				multipliers[defending.Name] = 0
+			}
+			chart[move.Type] = multipliers
+		}
+	}
+	return chart, nil
+}
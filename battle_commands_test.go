@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+)
+
+func TestLearnedMoves(t *testing.T) {
+	config := newOfflineConfig(1)
+	pikachu, err := config.Client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := map[int]string{
+		1:  "growl,thunder-shock",
+		10: "growl,thunder-shock,tail-whip,thunder-wave",
+		13: "thunder-shock,tail-whip,thunder-wave,quick-attack", // growl is forgotten
+	}
+	for level, expected := range cases {
+		if got := strings.Join(learnedMoves(pikachu, level), ","); got != expected {
+			t.Errorf("level %d: expected %s, got %s", level, expected, got)
+		}
+	}
+}
+
+func TestTypeChartFromBundle(t *testing.T) {
+	config := newOfflineConfig(1)
+	ctx := context.Background()
+	pikachu, err := config.Client.GetPokemon(ctx, "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	player, err := newCombatant(ctx, config.Client, pikachu, 10, battle.StatsAt(baseStats(pikachu), 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chart, err := typeChart(ctx, config.Client, player)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := chart.Effectiveness("electric", []string{"water", "flying"}); got != 4 {
+		t.Errorf("expected electric to do 4x against water/flying, got %v", got)
+	}
+	if got := chart.Effectiveness("electric", []string{"ground"}); got != 0 {
+		t.Errorf("expected electric not to affect ground, got %v", got)
+	}
+}
+
+func TestBattleSession(t *testing.T) {
+	config := newOfflineConfig(3)
+	pokedex := NewPokedex()
+	pikachu, err := config.Client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	species, err := config.Client.GetPokemonSpecies(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wild := wildEncounter{Pokemon: "pikachu", Level: 13, Method: "walk", Area: "eterna-forest-area"}
+	config.Storage.Add(newCaughtPokemon(pikachu, species, wild, time.Now()))
+
+	script := `
+travel canalave-city-area
+battle pikachu
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitCommandFailed {
+		t.Fatalf("expected exit code %d, got %d", exitCommandFailed, code)
+	}
+	if config.Battle != nil {
+		t.Fatalf("expected no battle without an encounter")
+	}
+
+	config.Encounter = &wildEncounter{Pokemon: "wingull", Level: 13, Method: "surf", Area: "canalave-city-area"}
+	script = `
+battle pikachu
+travel eterna-forest-area
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitCommandFailed {
+		t.Fatalf("expected exit code %d, got %d", exitCommandFailed, code)
+	}
+	if config.Battle == nil || config.Travel.Location != "canalave-city-area" {
+		t.Fatalf("expected a battle that can't be left by travelling, got %+v in %q", config.Battle, config.Travel.Location)
+	}
+
+	// thunder-shock is 4x effective against wingull, pikachu wins well
+	// before running out of turns
+	for turn := 0; config.Battle != nil; turn++ {
+		if turn == 20 {
+			t.Fatalf("battle still going after %d turns", turn)
+		}
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader("fight thunder-shock\n"), "test"); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d", exitOK, code)
+		}
+	}
+	if config.Encounter != nil {
+		t.Errorf("expected the encounter to end with the battle")
+	}
+
+	// a level 13 wingull is worth 54*13/7 experience and 1 speed effort value
+	winner, _ := config.Storage.Find("pikachu")
+	if winner.Experience != battle.ExperienceAt(winner.GrowthRate, 13)+100 || winner.EVs != (battle.Stats{Speed: 1}) {
+		t.Errorf("expected pikachu to be rewarded for the win, got %d experience and %+v", winner.Experience, winner.EVs)
+	}
+}
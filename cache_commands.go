@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheArguments is how many arguments each cache subcommand takes.
+var cacheArguments = map[string]int{"stats": 0, "list": 0, "clear": 0, "evict": 1, "ttl": 1}
+
+func commandCache(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	cache := config.Client.Cache()
+	if cache == nil {
+		return reportFailure(errors.New("caching is off in this session"))
+	}
+	if len(commandWords) < 2 {
+		return usageError("Usage: cache stats|list|clear, cache evict <url>, cache ttl <duration>")
+	}
+
+	subcommand, args := commandWords[1], commandWords[2:]
+	expected, ok := cacheArguments[subcommand]
+	if !ok {
+		return usageError("Unknown cache command " + subcommand + ", expected stats, list, clear, evict or ttl")
+	}
+	if len(args) != expected {
+		return usageError("Expected " + strconv.Itoa(expected) + " arguments, but found " + strconv.Itoa(len(args)))
+	}
+
+	switch subcommand {
+	case "stats":
+		stats := cache.Stats()
+		entries := strconv.Itoa(stats.Entries)
+		if stats.MaxEntries > 0 {
+			entries += " of " + strconv.Itoa(stats.MaxEntries)
+		}
+		size := formatBytes(stats.Bytes)
+		if stats.MaxBytes > 0 {
+			size += " of " + formatBytes(stats.MaxBytes)
+		}
+		fmt.Println("Entries: " + entries + " (" + size + ")")
+		fmt.Println("Hits: " + strconv.Itoa(stats.Hits) + " (" + strconv.Itoa(stats.DiskHits) + " from disk)")
+		fmt.Println("Misses: " + strconv.Itoa(stats.Misses))
+		if lookups := stats.Hits + stats.Misses; lookups > 0 {
+			fmt.Println("Hit rate: " + strconv.Itoa(stats.Hits * 100 / lookups) + "%")
+		}
+		fmt.Println("Evictions: " + strconv.Itoa(stats.Evictions))
+		fmt.Println("Expirations: " + strconv.Itoa(stats.Expirations))
+		fmt.Println("Revalidations: " + strconv.Itoa(stats.Revalidations))
+		fmt.Println("TTL: " + stats.TTL.String())
+		for _, policy := range cache.Policies() {
+			fmt.Println(" - " + policy.TTL.String() + " for " + policy.Pattern)
+		}
+	case "list":
+		entries := cache.Entries()
+		if len(entries) == 0 {
+			fmt.Println("The cache is empty")
+			return nil
+		}
+		// most recently used first
+		for _, entry := range entries {
+			details := formatBytes(entry.Size) + ", " + entry.Age.Round(time.Second).String() + " old, ttl " + entry.TTL.String()
+			if entry.Stale {
+				details += ", stale"
+			}
+			fmt.Println(" - " + entry.Key + " (" + details + ")")
+		}
+	case "clear":
+		cleared := cache.Clear()
+		fmt.Println("Cleared " + strconv.Itoa(cleared) + " entries")
+	case "evict":
+		key := args[0]
+		if !strings.Contains(key, "://") {
+			key = config.Client.URL(key) // a path such as pokemon/pikachu/
+		}
+		evicted := cache.Evict(key)
+		if !evicted && !strings.HasSuffix(key, "/") {
+			// endpoint URLs end with a slash, it's easy to leave out
+			evicted = cache.Evict(key + "/")
+		}
+		if !evicted {
+			return reportFailure(errors.New(key + " is not cached"))
+		}
+		fmt.Println("Evicted " + key)
+	case "ttl":
+		ttl, err := time.ParseDuration(args[0])
+		if err != nil || ttl <= 0 {
+			return usageError("Expected a duration such as 30s or 5m, but found " + args[0])
+		}
+		cache.SetDuration(ttl)
+		if len(cache.Policies()) > 0 {
+			fmt.Println("Cache entries no policy covers now live for " + ttl.String())
+		} else {
+			fmt.Println("Cache entries now live for " + ttl.String())
+		}
+	}
+	return nil
+}
+
+// formatBytes prints a size the way a person would read it: 512 B, 1.5 KB,
+// 64.0 MB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	value, suffix := float64(n) / unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value / unit, next
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + suffix
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
+)
+
+func TestCacheCommands(t *testing.T) {
+	cache := pokecache.NewCache(time.Minute)
+	defer cache.Close()
+	config := newOfflineConfig(1)
+	config.Client = pokeapi.NewClient(pokeapi.Options{
+		HTTPClient: &http.Client{Transport: &pokeapi.OfflineTransport{Dir: "testdata/bundle"}},
+		Cache:      cache,
+	})
+	pokedex := NewPokedex()
+	run := func(script string) {
+		t.Helper()
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+			t.Fatalf("script %q: expected exit code %d, got %d", script, exitOK, code)
+		}
+	}
+
+	run("explore canalave-city-area\nexplore eterna-forest-area\nexplore canalave-city-area\ncache stats\ncache list\n")
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("expected 2 entries, 1 hit and 2 misses, got %+v", stats)
+	}
+
+	run("cache evict location-area/canalave-city-area\n")
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader("cache evict location-area/nowhere\n"), "test"); code != exitCommandFailed {
+		t.Errorf("expected evicting what isn't cached to fail, got exit code %d", code)
+	}
+	if _, ok := cache.Get(config.Client.URL("location-area/canalave-city-area/")); ok {
+		t.Errorf("expected canalave-city-area to be evicted")
+	}
+
+	run("cache ttl 90s\n")
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader("cache ttl never\n"), "test"); code != exitUsage {
+		t.Errorf("expected a TTL that isn't a duration to be a usage error, got exit code %d", code)
+	}
+	if ttl := cache.Stats().TTL; ttl != 90*time.Second {
+		t.Errorf("expected a 90s TTL, got %v", ttl)
+	}
+
+	for _, script := range []string{"cache bogus\n", "cache bogus location-area/nowhere\n"} {
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitUsage {
+			t.Errorf("script %q: expected exit code %d, got %d", script, exitUsage, code)
+		}
+	}
+
+	run("cache clear\n")
+	if entries := cache.Entries(); len(entries) != 0 {
+		t.Errorf("expected an empty cache, got %v", entries)
+	}
+}
+
+func TestExitClosesCache(t *testing.T) {
+	before := runtime.NumGoroutine()
+	config := newOfflineConfig(1)
+	config.Client = pokeapi.NewClient(pokeapi.Options{Cache: pokecache.NewCache(time.Minute)})
+	pokedex := NewPokedex()
+	runScript(Commands(config), config, &pokedex, strings.NewReader("exit\n"), "test")
+
+	// exit stops the cache's reaper
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected exit to close the cache, %d goroutines left over", runtime.NumGoroutine()-before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachePolicies(t *testing.T) {
+	cache, err := pokecache.NewCacheWithOptions(pokecache.Options{Duration: 5 * time.Second, Policies: cachePolicies})
+	if err != nil {
+		t.Fatalf("expected the cache policies to be valid: %v", err)
+	}
+	defer cache.Close()
+	client := pokeapi.NewClient(pokeapi.Options{})
+	expected := map[string]time.Duration{
+		client.LocationAreaPageURL(0, 20):          30 * 24 * time.Hour,
+		client.URL("location-area/eterna-forest/"): 7 * 24 * time.Hour,
+		client.URL("pokemon/pikachu/"):             7 * 24 * time.Hour,
+		client.URL("pokemon-species/pikachu/"):     7 * 24 * time.Hour,
+		client.URL("berry/cheri/"):                 5 * time.Second,
+	}
+	for url := range expected {
+		cache.Add(url, []byte("{}"))
+	}
+	for _, entry := range cache.Entries() {
+		if entry.TTL != expected[entry.Key] {
+			t.Errorf("%s: expected a TTL of %v, got %v", entry.Key, expected[entry.Key], entry.TTL)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:        "0 B",
+		512:      "512 B",
+		1536:     "1.5 KB",
+		64 << 20: "64.0 MB",
+		3 << 30:  "3.0 GB",
+	}
+	for n, expected := range cases {
+		if got := formatBytes(n); got != expected {
+			t.Errorf("%d: expected %s, got %s", n, expected, got)
+		}
+	}
+}
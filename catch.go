@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// randSource is what the game mechanics draw random numbers from, a
+// *rand.Rand in the REPL and a seeded or scripted one in tests.
+type randSource interface {
+	Intn(n int) int
+}
+
+// ball modifiers from the mainline games, a master ball never fails
+var balls = map[string]float64{
+	"poke-ball":    1,
+	"great-ball":   1.5,
+	"ultra-ball":   2,
+	"safari-ball":  1.5,
+	"premier-ball": 1,
+	"master-ball":  255,
+}
+
+const defaultBall = "poke-ball"
+
+func ballNames() []string {
+	names := make([]string, 0, len(balls))
+	for name := range balls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// catchResult says how a throw went, shakes is how many times the ball
+// shook before the Pokemon broke free (4 when it was caught).
+type catchResult struct {
+	caught bool
+	shakes int
+}
+
+// attemptCatch runs the generation III/IV capture algorithm. currentHP
+// is the wild Pokemon's remaining HP out of maxHP, statusBonus is 1 for
+// no status, 1.5 for paralysis, poison or burn and 2 for sleep or freeze.
+func attemptCatch(rng randSource, captureRate int, ballName string, maxHP, currentHP int, statusBonus float64) catchResult {
+	if ballName == "master-ball" {
+		return catchResult{caught: true, shakes: 4}
+	}
+
+	a := catchValue(captureRate, balls[ballName], maxHP, currentHP, statusBonus)
+	if a >= 255 {
+		return catchResult{caught: true, shakes: 4}
+	}
+
+	b := shakeThreshold(a)
+	for shakes := 0; shakes < 4; shakes++ {
+		if rng.Intn(65536) >= b {
+			return catchResult{caught: false, shakes: shakes}
+		}
+	}
+	return catchResult{caught: true, shakes: 4}
+}
+
+// catchValue is the modified catch rate "a":
+// ((3*maxHP - 2*currentHP) * rate * ball) / (3*maxHP) * status
+func catchValue(captureRate int, ballBonus float64, maxHP, currentHP int, statusBonus float64) int {
+	if maxHP <= 0 {
+		maxHP = 1
+	}
+	currentHP = max(1, min(currentHP, maxHP))
+	a := math.Floor(float64(3*maxHP-2*currentHP) * float64(captureRate) * ballBonus / float64(3*maxHP))
+	a = math.Floor(a * statusBonus)
+	return max(1, int(a))
+}
+
+// shakeThreshold is "b", every shake check passes when a random number
+// in [0, 65535] is below it: 1048560 / sqrt(sqrt(16711680 / a))
+func shakeThreshold(a int) int {
+	return int(1048560 / math.Floor(math.Sqrt(math.Floor(math.Sqrt(math.Floor(16711680/float64(a)))))))
+}
+
+// catchProbability is the chance that attemptCatch succeeds, (b/65536)^4.
+func catchProbability(captureRate int, ballName string, maxHP, currentHP int, statusBonus float64) float64 {
+	if ballName == "master-ball" {
+		return 1
+	}
+	a := catchValue(captureRate, balls[ballName], maxHP, currentHP, statusBonus)
+	if a >= 255 {
+		return 1
+	}
+	return math.Pow(float64(shakeThreshold(a))/65536, 4)
+}
+
+// ballDisplayName turns "ultra-ball" into "Ultra Ball".
+func ballDisplayName(ballName string) string {
+	words := strings.Split(ballName, "-")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
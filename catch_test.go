@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// scriptedRand returns the given numbers in order.
+type scriptedRand struct {
+	numbers []int
+}
+
+func (r *scriptedRand) Intn(n int) int {
+	next := r.numbers[0]
+	r.numbers = r.numbers[1:]
+	return next
+}
+
+func TestCatchValue(t *testing.T) {
+	cases := []struct {
+		name        string
+		captureRate int
+		ball        string
+		maxHP, hp   int
+		status      float64
+		expected    int
+	}{
+		// full hp: (3*100 - 2*100) * 45 * 1 / 300 = 15
+		{name: "full hp", captureRate: 45, ball: "poke-ball", maxHP: 100, hp: 100, status: 1, expected: 15},
+		// one hp left: (300 - 2) * 45 / 300 = 44.7
+		{name: "one hp", captureRate: 45, ball: "poke-ball", maxHP: 100, hp: 1, status: 1, expected: 44},
+		{name: "ultra ball", captureRate: 45, ball: "ultra-ball", maxHP: 100, hp: 100, status: 1, expected: 30},
+		{name: "asleep", captureRate: 45, ball: "poke-ball", maxHP: 100, hp: 100, status: 2, expected: 30},
+		{name: "easy", captureRate: 255, ball: "great-ball", maxHP: 100, hp: 50, status: 1, expected: 255},
+		{name: "never below 1", captureRate: 3, ball: "poke-ball", maxHP: 300, hp: 300, status: 1, expected: 1},
+	}
+	for _, c := range cases {
+		got := catchValue(c.captureRate, balls[c.ball], c.maxHP, c.hp, c.status)
+		if got != c.expected {
+			t.Errorf("%s: expected a = %d, got %d", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestShakeChecks(t *testing.T) {
+	// captureRate 45 at full hp gives a = 15, and 16711680 / 15 = 1114112
+	// whose square root is 1055, whose square root is 32
+	b := shakeThreshold(15)
+	if b != 1048560/32 {
+		t.Fatalf("expected threshold %d, got %d", 1048560/32, b)
+	}
+
+	cases := []struct {
+		numbers  []int
+		expected catchResult
+	}{
+		{numbers: []int{0, 0, 0, 0}, expected: catchResult{caught: true, shakes: 4}},
+		{numbers: []int{0, 0, b, 0}, expected: catchResult{caught: false, shakes: 2}},
+		{numbers: []int{b - 1, b - 1, b - 1, b}, expected: catchResult{caught: false, shakes: 3}},
+		{numbers: []int{65535}, expected: catchResult{caught: false, shakes: 0}},
+	}
+	for _, c := range cases {
+		got := attemptCatch(&scriptedRand{numbers: c.numbers}, 45, "poke-ball", 100, 100, 1)
+		if got != c.expected {
+			t.Errorf("%v: expected %+v, got %+v", c.numbers, c.expected, got)
+		}
+	}
+}
+
+func TestGuaranteedCatches(t *testing.T) {
+	// neither of these may draw a random number at all
+	noRandom := &scriptedRand{}
+	if result := attemptCatch(noRandom, 3, "master-ball", 100, 100, 1); !result.caught {
+		t.Errorf("expected a master ball to always catch")
+	}
+	if result := attemptCatch(noRandom, 255, "ultra-ball", 100, 1, 1); !result.caught {
+		t.Errorf("expected a >= 255 to always catch")
+	}
+}
+
+func TestCatchProbabilityMatchesSimulation(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	const trials = 40000
+	cases := []struct {
+		captureRate int
+		ball        string
+		hp          int
+	}{
+		{captureRate: 45, ball: "poke-ball", hp: 100},  // a starter at full health
+		{captureRate: 45, ball: "ultra-ball", hp: 10},  // weakened, better ball
+		{captureRate: 190, ball: "poke-ball", hp: 100}, // a common pokemon
+		{captureRate: 3, ball: "ultra-ball", hp: 1},    // a legendary
+	}
+	for _, c := range cases {
+		caught := 0
+		for i := 0; i < trials; i++ {
+			if attemptCatch(rng, c.captureRate, c.ball, 100, c.hp, 1).caught {
+				caught++
+			}
+		}
+		expected := catchProbability(c.captureRate, c.ball, 100, c.hp, 1)
+		got := float64(caught) / trials
+		if math.Abs(got-expected) > 0.015 {
+			t.Errorf("rate %d with %s at %d hp: expected p = %.3f, simulated %.3f", c.captureRate, c.ball, c.hp, expected, got)
+		}
+	}
+
+	// weakening and better balls must only ever help
+	if catchProbability(45, "poke-ball", 100, 100, 1) >= catchProbability(45, "poke-ball", 100, 1, 1) {
+		t.Errorf("expected a weakened pokemon to be easier to catch")
+	}
+	if catchProbability(45, "poke-ball", 100, 100, 1) >= catchProbability(45, "ultra-ball", 100, 100, 1) {
+		t.Errorf("expected an ultra ball to beat a poke ball")
+	}
+}
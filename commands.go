@@ -1,20 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"net/http"
-	"encoding/json"
-	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
-	"io"
-	"math/rand"
 	"strconv"
+	"strings"
+	"time"
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
 )
 
 type cliCommand struct {
 	name        string
 	description string
-	callback    func(*Config, *pokecache.Cache, []string, *Pokedex) error
+	callback    func(context.Context, *Config, []string, *Pokedex) error
+	keepCase    bool // the arguments are used as typed, like file paths
 }
 
 func Commands(config *Config) map[string]cliCommand {
@@ -29,26 +31,123 @@ func Commands(config *Config) map[string]cliCommand {
 			description: "Displays the previous 20 names of location areas in the Pokemon world",
 			callback:    commandMapb,
 		},
+		"travel": {
+			name:        "travel",
+			description: "Travels to a location area, where encounters then happen: travel <area>",
+			callback:    commandTravel,
+		},
+		"where": {
+			name:        "where",
+			description: "Shows the location area you are in and where you travelled before",
+			callback:    commandWhere,
+		},
 		"explore": {
 			name:        "explore",
-			description: "Displays the names of pokemons located in specific location area in the Pokemon world",
+			description: "Displays the names of pokemons located in a location area, the current one by default",
 			callback:    commandExplore,
 		},
+		"encounter": {
+			name:        "encounter",
+			description: "Looks for a wild Pokemon in the current location area: encounter [walk|surf|old-rod|...]",
+			callback:    commandEncounter,
+		},
 		"catch": {
 			name:        "catch",
-			description: "Catches a Pokemon and adds it to the user's Pokedex",
+			description: "Throws a ball (poke-ball by default) at the encountered Pokemon: catch <pokemon> [ball]",
 			callback:    commandCatch,
 		},
+		"battle": {
+			name:        "battle",
+			description: "Sends a party Pokemon to fight the encountered one: battle <pokemon|id>",
+			callback:    commandBattle,
+		},
+		"fight": {
+			name:        "fight",
+			description: "Uses a move in the current battle: fight <move>",
+			callback:    commandFight,
+		},
+		"run": {
+			name:        "run",
+			description: "Runs from the current battle or encounter",
+			callback:    commandRun,
+		},
+		"party": {
+			name:        "party",
+			description: "Lists the Pokemon in your party",
+			callback:    commandParty,
+		},
+		"box": {
+			name:        "box",
+			description: "Lists the Pokemon in a PC box: box [1-8]",
+			callback:    commandBox,
+		},
+		"deposit": {
+			name:        "deposit",
+			description: "Moves a party Pokemon to a PC box: deposit <pokemon|id> [box]",
+			callback:    commandDeposit,
+		},
+		"withdraw": {
+			name:        "withdraw",
+			description: "Moves a Pokemon from its PC box to your party: withdraw <pokemon|id>",
+			callback:    commandWithdraw,
+		},
+		"swap": {
+			name:        "swap",
+			description: "Swaps the places of two of your Pokemon: swap <pokemon|id> <pokemon|id>",
+			callback:    commandSwap,
+		},
+		"release": {
+			name:        "release",
+			description: "Releases one of your Pokemon for good: release <pokemon|id>",
+			callback:    commandRelease,
+		},
+		"evolve": {
+			name:        "evolve",
+			description: "Evolves one of your Pokemon if it's ready, by levelling, an item or a trade: evolve <pokemon|id> [item|trade]",
+			callback:    commandEvolve,
+		},
 		"inspect": {
 			name:        "inspect",
-			description: "Shows details about only a caught Pokemon",
+			description: "Shows details about one of your Pokemon and its evolution chain: inspect <pokemon|id>",
 			callback:    commandInspect,
 		},
 		"pokedex": {
 			name:        "pokedex",
-			description: "Prints a list of all the names of the Pokemon the user has caught",
+			description: "Lists the Pokemon species you have seen and caught",
 			callback:    commandPokedex,
 		},
+		"save": {
+			name:        "save",
+			description: "Saves the Pokedex, to the default save file or to the given path",
+			callback:    commandSave,
+			keepCase:    true,
+		},
+		"load": {
+			name:        "load",
+			description: "Loads the Pokedex, from the default save file or from the given path",
+			callback:    commandLoad,
+			keepCase:    true,
+		},
+		"profile": {
+			name:        "profile",
+			description: "Manages trainer profiles: profile new|switch|delete <name>, profile list",
+			callback:    commandProfile,
+		},
+		"stats": {
+			name:        "stats",
+			description: "Shows the current profile's statistics",
+			callback:    commandStats,
+		},
+		"set": {
+			name:        "set",
+			description: "Changes a setting: set output text|json|yaml|csv, set verbose on|off, set timeout <duration>",
+			callback:    commandSet,
+		},
+		"cache": {
+			name:        "cache",
+			description: "Inspects and tunes the PokeAPI cache: cache stats|list|clear, cache evict <url>, cache ttl <duration>",
+			callback:    commandCache,
+		},
 		"help": {
 			name:        "help",
 			description: "Displays a help message",
@@ -62,22 +161,32 @@ func Commands(config *Config) map[string]cliCommand {
 	}
 }
 
-func commandExit(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
+func commandExit(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
 	if len(commandWords) != 1 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
 	}
 	fmt.Println("Closing the Pokedex... Goodbye!")
-	os.Exit(0)
-	return nil
+	closeCache(config)
+	return errExit // main autosaves and exits
 }
 
-func commandHelp(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
+// closeCache stops the cache and flushes whatever it couldn't write to
+// disk earlier. Main calls it again on the way out, in case the session
+// ended without exit.
+func closeCache(config *Config) {
+	if config.Client == nil || config.Client.Cache() == nil {
+		return
+	}
+	if err := config.Client.Cache().Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "error flushing the cache: " + err.Error())
+	}
+}
+
+func commandHelp(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
 	if len(commandWords) != 1 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
 	}
 	fmt.Println("Welcome to the Pokedex!")
 	fmt.Println("Usage:")
@@ -88,59 +197,49 @@ func commandHelp(config *Config, cache *pokecache.Cache, commandWords []string,
 	return nil
 }
 
-func commandMap(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
+func commandMap(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
 	if len(commandWords) != 1 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
 	}
 	if config.Next == nil {
-		fmt.Println("you're on the last page")
-		return nil
+		return reportFailure(errors.New("you're on the last page"))
 	}
-	return fetchingLocationAreaMap(*(config.Next), config, cache)
+	return fetchingLocationAreaMap(ctx, *(config.Next), config)
 }
 
-func commandMapb(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
+func commandMapb(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
 	if len(commandWords) != 1 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
 	}
 	if config.Previous == nil {
-		fmt.Println("you're on the first page")
-		return nil
+		return reportFailure(errors.New("you're on the first page"))
 	}
-	return fetchingLocationAreaMap(*(config.Previous), config, cache)
+	return fetchingLocationAreaMap(ctx, *(config.Previous), config)
 }
 
-func fetchingLocationAreaMap(url string, config *Config, cache *pokecache.Cache) error {
-	var locationAreaMap LocationAreaMap
+func fetchingLocationAreaMap(ctx context.Context, url string, config *Config) error {
+	locationAreaMap, err := config.Client.ListLocationAreas(ctx, url)
+	if err != nil {
+		return reportAPIError(err, "that page of location areas doesn't exist")
+	}
+	for _, locationArea := range locationAreaMap.Results {
+		config.rememberArea(locationArea.Name)
+	}
 
-	var Response []byte
-	cachedRes, ok := cache.Get(url)
-	if !ok {
-		res, err := http.Get(url)
-		if err != nil {
-			return fmt.Errorf("error fetching location areas map: %w", err)
-		}
-		Response, err = io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("error reading location areas json data from response: %w", err)
+	if !config.Output.structured() {
+		for _, locationArea := range locationAreaMap.Results {
+			fmt.Println(locationArea.Name)
 		}
-		cache.Add(url, Response)
-		fmt.Println("DATA FETCHED FROM INTERNET")
 	} else {
-		Response = cachedRes
-		fmt.Println("DATA FOUND IN THE CACHE")
-	}
-
-	if err := json.Unmarshal(Response, &locationAreaMap); err != nil {
-		return fmt.Errorf("error parsing location areas json-encoded data: %w", err)
-	}
-	
-	for _, locationArea := range locationAreaMap.Results {
-		fmt.Println(locationArea.Name)
+		records := make([]Record, 0, len(locationAreaMap.Results))
+		for _, locationArea := range locationAreaMap.Results {
+			records = append(records, Record{{"name", locationArea.Name}})
+		}
+		if err := printRecords(os.Stdout, config.Output, records); err != nil {
+			return err
+		}
 	}
 
 	if locationAreaMap.Previous != nil {
@@ -156,44 +255,44 @@ func fetchingLocationAreaMap(url string, config *Config, cache *pokecache.Cache)
 	return nil
 }
 
-func commandExplore(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
-	if len(commandWords) != 2 {
+func commandExplore(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) > 2 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 or 1 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	areaName := config.Travel.Location
+	if len(commandWords) == 2 {
+		areaName = commandWords[1]
+	}
+	if areaName == "" {
+		return reportFailure(errors.New("you are nowhere yet, travel to a location area or name one to explore"))
+	}
+	if !config.Output.structured() {
+		fmt.Println("Exploring " + areaName + "...")
 	}
-	fmt.Println("Exploring " + commandWords[1] + "...")
-
-	fullURL := "https://pokeapi.co/api/v2/location-area/" + commandWords[1] + "/"
-
-	var Response []byte
-	cachedRes, ok := cache.Get(fullURL)
-	if !ok {
-		res, err := http.Get(fullURL)
-		if err != nil {
-			return fmt.Errorf("error fetching this location area's data: %w", err)
-		}
-
-		Response, err = io.ReadAll(res.Body)
-		if err != nil {
-			return fmt.Errorf("error reading location area's json data from response: %w", err)
-		}
 
-		cache.Add(fullURL, Response)
-		fmt.Println("DATA FETCHED FROM INTERNET")
-	} else {
-		Response = cachedRes
-		fmt.Println("DATA FOUND IN THE CACHE")
+	locationArea, err := config.Client.GetLocationArea(ctx, areaName)
+	if err != nil {
+		return reportAPIError(err, "there is no location area called " + areaName)
 	}
-	
+	if locationArea.Name == config.Travel.Location {
+		config.areaMethods = encounterMethods(locationArea)
+	}
+	config.Stats.AreasExplored++
 
-	var locationArea LocationArea
-	if err := json.Unmarshal(Response, &locationArea); err != nil {
-		return fmt.Errorf("error parsing this location area's json-encoded data: %w", err)
+	pokemons := locationArea.PokemonEncounters
+	if config.Output.structured() {
+		records := make([]Record, 0, len(pokemons))
+		for _, item := range pokemons {
+			records = append(records, Record{
+				{"location_area", locationArea.Name},
+				{"name", item.Pokemon.Name},
+			})
+		}
+		return printRecords(os.Stdout, config.Output, records)
 	}
 
 	fmt.Println("Found Pokemon:")
-	pokemons := locationArea.PokemonEncounters
 	for _, item := range pokemons {
 		fmt.Println(" - " + item.Pokemon.Name)
 	}
@@ -201,120 +300,380 @@ func commandExplore(config *Config, cache *pokecache.Cache, commandWords []strin
 	return nil
 }
 
-func commandCatch(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
-	if len(commandWords) != 2 {
+func commandEncounter(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) > 2 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 0 or 1 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Battle != nil {
+		return reportFailure(errors.New("you are in a battle with " + config.Battle.Wild.Name + ", fight, catch it or run first"))
+	}
+	if config.Travel.Location == "" {
+		return reportFailure(errors.New("you are nowhere yet, travel to a location area first"))
 	}
-	fmt.Println("Throwing a Pokeball at " + commandWords[1] + "...")
 
-	fullURL := "https://pokeapi.co/api/v2/pokemon/" + commandWords[1] + "/"
+	locationArea, err := config.Client.GetLocationArea(ctx, config.Travel.Location)
+	if err != nil {
+		return reportAPIError(err, "there is no location area called " + config.Travel.Location)
+	}
+	methods := encounterMethods(locationArea)
+	if len(methods) == 0 {
+		return reportFailure(errors.New("there are no wild pokemon in " + locationArea.Name))
+	}
+
+	method := methods[0]
+	if len(commandWords) == 2 {
+		method = commandWords[1]
+	} else {
+		for _, name := range methods {
+			if name == defaultEncounterMethod {
+				method = name
+			}
+		}
+	}
+	slot, level, ok := rollEncounter(config.Rand, encounterTable(locationArea, method))
+	if !ok {
+		return reportFailure(errors.New("no pokemon can be found with " + method + " here, try one of: " + strings.Join(methods, ", ")))
+	}
+
+	config.Encounter = &wildEncounter{
+		Pokemon: slot.pokemon,
+		Level:   level,
+		Method:  method,
+		Area:    locationArea.Name,
+		IVs:     battle.RandomIVs(config.Rand),
+		Nature:  battle.RandomNature(config.Rand).Name,
+	}
+	pokedex.MarkSeen(slot.pokemon)
+	if config.Output.structured() {
+		return printRecord(os.Stdout, config.Output, Record{
+			{"location_area", locationArea.Name},
+			{"method", method},
+			{"name", slot.pokemon},
+			{"level", level},
+		})
+	}
+	fmt.Println("A wild " + slot.pokemon + " (level " + strconv.Itoa(level) + ") appeared!")
+	return nil
+}
+
+func commandCatch(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 && len(commandWords) != 3 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 or 2 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	ballName := defaultBall
+	if len(commandWords) == 3 {
+		ballName = commandWords[2]
+	}
+	if _, ok := balls[ballName]; !ok {
+		return usageError("there is no ball called " + ballName + ", try one of: " + strings.Join(ballNames(), ", "))
+	}
+	if config.Travel.Location == "" {
+		return reportFailure(errors.New("you are nowhere yet, travel to a location area first"))
+	}
+	locationArea, err := config.Client.GetLocationArea(ctx, config.Travel.Location)
+	if err != nil {
+		return reportAPIError(err, "there is no location area called " + config.Travel.Location)
+	}
+	if !canAppearIn(locationArea, commandWords[1]) {
+		return reportFailure(errors.New(commandWords[1] + " can't be found in " + locationArea.Name))
+	}
+	if config.Encounter == nil || config.Encounter.Pokemon != commandWords[1] {
+		return reportFailure(errors.New("there is no wild " + commandWords[1] + " in front of you, use encounter to find one"))
+	}
+	if config.Storage.firstWithRoom(0) < 0 {
+		return reportFailure(errStorageFull) // don't waste the ball on a pokemon that can't be kept
+	}
 
-	pokemon, err := fetchPokemon(fullURL, cache)
+	pokemon, err := config.Client.GetPokemon(ctx, commandWords[1])
 	if err != nil {
-		return err
+		return reportAPIError(err, "there is no pokemon called " + commandWords[1])
+	}
+	species, err := config.Client.GetPokemonSpecies(ctx, pokemon.Species.Name)
+	if err != nil {
+		return reportAPIError(err, "there is no species data for " + commandWords[1])
+	}
+
+	fmt.Println("Throwing a " + ballDisplayName(ballName) + " at " + commandWords[1] + "...")
+
+	// a wild pokemon is at full health unless a battle weakened it
+	maxHP := wildStats(pokemon, *config.Encounter).HP
+	currentHP := maxHP
+	if config.Battle != nil {
+		maxHP, currentHP = config.Battle.Wild.Stats.HP, config.Battle.Wild.HP
 	}
+	result := attemptCatch(config.Rand, species.CaptureRate, ballName, maxHP, currentHP, 1)
 
-	// pokemon.BaseExperience
-	randomNumber := rand.Intn(400)
-	if randomNumber > pokemon.BaseExperience {
+	config.Stats.CatchAttempts++
+	for i := 0; i < min(result.shakes, 3); i++ {
+		fmt.Println("...the ball shakes...")
+	}
+	if result.caught {
 		fmt.Println(commandWords[1] + " was caught!")
-		pokedex.Add(pokemon)
+		caught, box, err := config.Storage.Add(newCaughtPokemon(pokemon, species, *config.Encounter, config.now()))
+		if err != nil {
+			return reportFailure(err)
+		}
+		if box > 0 {
+			fmt.Println("your party is full, " + caught.Name + " (#" + strconv.Itoa(caught.ID) + ") was sent to box " + strconv.Itoa(box))
+		}
+		pokedex.MarkCaught(pokemon.Name)
+		config.Encounter = nil
+		config.Battle = nil
+		config.Stats.Caught++
 	} else {
 		fmt.Println(commandWords[1] + " escaped!")
+		config.Stats.Escaped++
 	}
 
 	return nil
 }
 
-func commandInspect(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
+func commandInspect(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
 	if len(commandWords) != 2 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
 	}
 
-	found := false
-	for _, poke := range pokedex.Items {
-		if poke.Name == commandWords[1] {
-			found = true
-			break
-		}
-	} 
-	if !found {
-		fmt.Println("you have not caught that pokemon")
-		return nil
+	caught, err := config.Storage.Find(commandWords[1])
+	if err != nil {
+		return reportFailure(err)
 	}
-
-	fullURL := "https://pokeapi.co/api/v2/pokemon/" + commandWords[1] + "/"
-
-	pokemon, err := fetchPokemon(fullURL, cache)
+	pokemon, err := config.Client.GetPokemon(ctx, caught.Name)
 	if err != nil {
-		return err
+		return reportAPIError(err, "there is no pokemon called " + caught.Name)
+	}
+	stats := caught.ActualStats(pokemon)
+	base := baseStats(pokemon)
+	// the rest is known by now, so it's shown even when the chain can't be
+	// fetched
+	evolution := evolutionUnavailable
+	if chain, err := evolutionChain(ctx, config.Client, caught.Species); err == nil {
+		evolution = chainText(chain.Chain)
 	}
 
-	fmt.Println("Name: " + pokemon.Name)
+	if config.Output.structured() {
+		statRecord := func(values battle.Stats) Record {
+			record := Record{}
+			for _, name := range battle.StatNames {
+				record = append(record, Field{name, values.Get(name)})
+			}
+			return record
+		}
+		types := []string{}
+		for _, item := range pokemon.Types {
+			types = append(types, item.Type.Name)
+		}
+		return printRecord(os.Stdout, config.Output, Record{
+			{"id", caught.ID},
+			{"name", pokemon.Name},
+			{"level", caught.Level},
+			{"experience", caught.Experience},
+			{"nature", caught.Nature},
+			{"friendship", caught.Friendship},
+			{"height", pokemon.Height},
+			{"weight", pokemon.Weight},
+			{"stats", statRecord(stats)},
+			{"base_stats", statRecord(base)},
+			{"ivs", statRecord(caught.IVs)},
+			{"evs", statRecord(caught.EVs)},
+			{"types", types},
+			{"evolution_chain", evolution},
+		})
+	}
+
+	fmt.Println("Name: " + pokemon.Name + " (#" + strconv.Itoa(caught.ID) + ")")
+	fmt.Println("Level: " + strconv.Itoa(caught.Level))
+	if next := caught.toNextLevel(); next > 0 {
+		fmt.Println("Experience: " + strconv.Itoa(caught.Experience) + " (" + strconv.Itoa(next) + " to the next level)")
+	} else {
+		fmt.Println("Experience: " + strconv.Itoa(caught.Experience))
+	}
+	fmt.Println("Nature: " + caught.Nature)
+	fmt.Println("Friendship: " + strconv.Itoa(caught.Friendship))
 	fmt.Println("Height: " + strconv.Itoa(pokemon.Height))
 	fmt.Println("Weight: " + strconv.Itoa(pokemon.Weight))
 	fmt.Println("Stats:")
-	for _, item := range pokemon.Stats {
-		fmt.Println("  -" + item.Stat.Name + ": " + strconv.Itoa(item.BaseStat))
+	for _, name := range battle.StatNames {
+		fmt.Println("  -" + name + ": " + strconv.Itoa(stats.Get(name)) + " (base " + strconv.Itoa(base.Get(name)) + ", iv " + strconv.Itoa(caught.IVs.Get(name)) + ", ev " + strconv.Itoa(caught.EVs.Get(name)) + ")")
 	}
 	fmt.Println("Types:")
 	for _, item := range pokemon.Types {
 		fmt.Println("  -" + item.Type.Name)
 	}
+	fmt.Println("Evolution: " + evolution)
 
 	return nil
 }
 
-func fetchPokemon(fullURL string, cache *pokecache.Cache) (Pokemon, error) {
-	var pokemon Pokemon
+func commandPokedex(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 1 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
+	}
 
-	var Response []byte
-	cachedData, ok := cache.Get(fullURL)
-	if !ok {
-		res, err := http.Get(fullURL)
-		if err != nil {
-			return pokemon, fmt.Errorf("error fetching this pokemon's data: %w", err)
+	names := pokedex.Names()
+	if config.Output.structured() {
+		records := make([]Record, 0, len(names))
+		for _, name := range names {
+			entry := pokedex.Entries[name]
+			records = append(records, Record{
+				{"name", name},
+				{"seen", entry.Seen},
+				{"caught", entry.Caught},
+			})
 		}
+		return printRecords(os.Stdout, config.Output, records)
+	}
 
-		Response, err = io.ReadAll(res.Body)
-		if err != nil {
-			return pokemon, fmt.Errorf("error reading pokemons's json data from response: %w", err)
+	if len(names) == 0 {
+		fmt.Println("Your Pokedex is empty!")
+		return nil
+	}
+
+	fmt.Println("Your Pokedex (" + strconv.Itoa(len(names)) + " seen, " + strconv.Itoa(pokedex.CaughtCount()) + " caught):")
+	for _, name := range names {
+		if pokedex.HasCaught(name) {
+			fmt.Println(" - " + name)
+		} else {
+			fmt.Println(" - " + name + " (seen)")
 		}
+	}
 
-		cache.Add(fullURL, Response)
-		fmt.Println("DATA FETCHED FROM INTERNET")
-	} else {
-		Response = cachedData
-		fmt.Println("DATA FOUND IN THE CACHE")
+	return nil
+}
+
+func commandSave(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) > 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected at most 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	path := config.SavePath
+	if len(commandWords) == 2 {
+		path = commandWords[1]
 	}
-	
-	if err := json.Unmarshal(Response, &pokemon); err != nil {
-		return pokemon, fmt.Errorf("error parsing this pokemon's json-encoded data: %w", err)
+	if path == "" {
+		return reportFailure(errors.New("no save file path available, pass one: save <path>"))
 	}
 
-	return pokemon, nil
+	if err := saveGame(path, config, pokedex); err != nil {
+		return err
+	}
+	fmt.Println("Pokedex saved to " + path)
+	return nil
 }
 
-func commandPokedex(config *Config, cache *pokecache.Cache, commandWords []string, pokedex *Pokedex) error {
-	if len(commandWords) != 1 {
+func commandLoad(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) > 2 {
 		foundArguments := len(commandWords) - 1
-		fmt.Println("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
-		return nil
+		return usageError("Expected at most 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	path := config.SavePath
+	if len(commandWords) == 2 {
+		path = commandWords[1]
+	}
+	if path == "" {
+		return reportFailure(errors.New("no save file path available, pass one: load <path>"))
 	}
 
-	if len(pokedex.Items) == 0 {
-		fmt.Println("Your Pokedex is empty!")
-		return nil
+	save, err := loadGame(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return reportFailure(errors.New("there is no save file at " + path))
+	}
+	if err != nil {
+		return err
 	}
+	save.apply(config, pokedex)
+	fmt.Println("Pokedex loaded from " + path + " (" + strconv.Itoa(len(config.Storage.All())) + " Pokemon)")
+	return nil
+}
 
-	fmt.Println("Your Pokedex:")
-	for _, pokemon := range pokedex.Items {
-		fmt.Println(" - " + pokemon.Name)
-	} 
+func commandSet(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 3 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 2 arguments, but found " + strconv.Itoa(foundArguments))
+	}
 
+	switch commandWords[1] {
+	case "output":
+		format, err := parseOutputFormat(commandWords[2])
+		if err != nil {
+			return usageError(err.Error())
+		}
+		config.Output = format
+	case "verbose":
+		switch commandWords[2] {
+		case "on":
+			config.Client.SetDebug(os.Stderr)
+		case "off":
+			config.Client.SetDebug(nil)
+		default:
+			return usageError("Expected on or off, but found " + commandWords[2])
+		}
+	case "timeout":
+		timeout, err := time.ParseDuration(commandWords[2])
+		if err != nil || timeout < 0 {
+			return usageError("Expected a duration such as 10s or 0 for none, but found " + commandWords[2])
+		}
+		config.Client.SetTimeout(timeout)
+	default:
+		return usageError("Unknown setting " + commandWords[1] + ", expected output, verbose or timeout")
+	}
+	fmt.Fprintln(os.Stderr, commandWords[1] + " set to " + commandWords[2]) // not on stdout, it could be a json stream
 	return nil
-}
\ No newline at end of file
+}
+
+// autosave writes the current profile's save file when leaving it. A
+// failure is printed and returned, so the caller can hold on to the state
+// that wasn't saved.
+func autosave(config *Config, pokedex *Pokedex) error {
+	if config.SavePath == "" {
+		return nil
+	}
+	err := saveGame(config.SavePath, config, pokedex)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "autosave failed: " + err.Error())
+	}
+	return err
+}
+
+// usageError prints msg, about a command given the wrong arguments, and
+// returns it marked as reported. It exits with exitUsage. Like every
+// failure it goes to stderr, stdout could be a json, yaml or csv stream.
+func usageError(msg string) error {
+	fmt.Fprintln(os.Stderr, msg)
+	return reportedError{fmt.Errorf("%w: %s", errUsage, msg)}
+}
+
+// reportFailure prints err on stderr, about a command that can't do what was asked
+// right now, and returns it marked as reported.
+func reportFailure(err error) error {
+	fmt.Fprintln(os.Stderr, err)
+	return reportedError{err}
+}
+
+// reportAPIError prints a friendly message on stderr for the API errors the user can
+// do something about and marks them as reported. Anything else is returned
+// as is.
+func reportAPIError(err error, notFoundMessage string) error {
+	switch {
+	case errors.Is(err, pokeapi.ErrNotFound):
+		fmt.Fprintln(os.Stderr, notFoundMessage)
+	case errors.Is(err, pokeapi.ErrNotAvailableOffline):
+		fmt.Fprintln(os.Stderr, "that is not available offline, it isn't in the bundle")
+	case errors.Is(err, pokeapi.ErrNotRecorded):
+		fmt.Fprintln(os.Stderr, "that request isn't in the replayed session")
+	case errors.Is(err, pokeapi.ErrRateLimited):
+		fmt.Fprintln(os.Stderr, "PokeAPI is limiting our requests, wait a moment and try again")
+	case errors.Is(err, pokeapi.ErrServerError):
+		fmt.Fprintln(os.Stderr, "PokeAPI is having trouble right now, try again later")
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Fprintln(os.Stderr, "PokeAPI didn't answer in time, try again or raise the timeout with set timeout")
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(os.Stderr, "cancelled")
+	default:
+		return err
+	}
+	return reportedError{err}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/OmarJarbou/pokedexcli/internal/lineedit"
+)
+
+// completer offers command names for the first word, and for the argument
+// the names the command can actually use: areas listed by map for explore
+// and travel, the current area's methods for encounter, the encountered
+// Pokemon for catch, owned Pokemon for inspect, evolve and the storage
+// commands, party Pokemon for battle, and the battling Pokemon's moves for
+// fight.
+func completer(commands map[string]cliCommand, config *Config, pokedex *Pokedex) lineedit.CompleteFunc {
+	return func(before string) []string {
+		words := strings.Fields(strings.ToLower(before))
+		if strings.HasSuffix(before, " ") {
+			words = append(words, "") // starting a new word
+		}
+		if len(words) <= 1 {
+			names := make([]string, 0, len(commands))
+			for name := range commands {
+				names = append(names, name)
+			}
+			return names
+		}
+
+		argument := len(words) - 1 // 1 for the first argument
+		switch words[0] {
+		case "explore", "travel":
+			if argument == 1 {
+				return keys(config.seenAreas)
+			}
+		case "encounter":
+			if argument == 1 {
+				return config.areaMethods
+			}
+		case "catch":
+			if argument == 1 && config.Encounter != nil {
+				return []string{config.Encounter.Pokemon}
+			}
+			if argument == 2 {
+				return ballNames()
+			}
+		case "fight":
+			if argument == 1 && config.Battle != nil {
+				return config.Battle.Player.MoveNames()
+			}
+		case "inspect", "release":
+			if argument == 1 {
+				return storedNames(config.Storage.All())
+			}
+		case "evolve":
+			if argument == 1 {
+				return storedNames(config.Storage.All())
+			}
+			if argument == 2 {
+				return []string{triggerTrade}
+			}
+		case "battle", "deposit":
+			if argument == 1 {
+				return storedNames(config.Storage.Party)
+			}
+		case "withdraw":
+			if argument == 1 {
+				var boxed []CaughtPokemon
+				for _, box := range config.Storage.Boxes {
+					boxed = append(boxed, box...)
+				}
+				return storedNames(boxed)
+			}
+		case "swap":
+			if argument <= 2 {
+				return storedNames(config.Storage.All())
+			}
+		case "set":
+			if argument == 1 {
+				return []string{"output", "verbose", "timeout"}
+			}
+			if argument == 2 && words[1] == "output" {
+				return []string{"text", "json", "yaml", "csv"}
+			}
+			if argument == 2 && words[1] == "verbose" {
+				return []string{"on", "off"}
+			}
+		case "cache":
+			if argument == 1 {
+				return []string{"stats", "list", "clear", "evict", "ttl"}
+			}
+			if argument == 2 && words[1] == "evict" && config.Client != nil && config.Client.Cache() != nil {
+				var urls []string
+				for _, entry := range config.Client.Cache().Entries() {
+					urls = append(urls, entry.Key)
+				}
+				return urls
+			}
+		case "profile":
+			if argument == 1 {
+				return []string{"new", "switch", "list", "delete"}
+			}
+			if argument == 2 && (words[1] == "switch" || words[1] == "delete") && config.Profiles != nil {
+				names, _ := config.Profiles.List()
+				return names
+			}
+		}
+		return nil
+	}
+}
+
+func storedNames(pokemons []CaughtPokemon) []string {
+	names := make([]string, len(pokemons))
+	for i, pokemon := range pokemons {
+		names[i] = pokemon.Name
+	}
+	return names
+}
+
+func keys(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	return names
+}
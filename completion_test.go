@@ -0,0 +1,44 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompleter(t *testing.T) {
+	config := newTestConfig()
+	config.rememberArea("canalave-city-area")
+	config.areaMethods = []string{"old-rod", "surf"}
+	config.Encounter = &wildEncounter{Pokemon: "tentacool", Level: 25, Method: "surf", Area: "canalave-city-area"}
+	pokedex := NewPokedex()
+	config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", CaughtAt: time.Now()})
+	complete := completer(Commands(config), config, &pokedex)
+
+	cases := []struct {
+		before   string
+		expected []string
+	}{
+		{before: "explore ", expected: []string{"canalave-city-area"}},
+		{before: "explore can", expected: []string{"canalave-city-area"}},
+		{before: "encounter ", expected: []string{"old-rod", "surf"}},
+		{before: "catch ", expected: []string{"tentacool"}},
+		{before: "inspect p", expected: []string{"pikachu"}},
+		{before: "set output ", expected: []string{"csv", "json", "text", "yaml"}},
+		{before: "inspect pikachu ", expected: nil}, // inspect takes one argument
+		{before: "map ", expected: nil},
+	}
+	for _, c := range cases {
+		got := complete(c.before)
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(c.expected, ",") {
+			t.Errorf("%q: expected %v, got %v", c.before, c.expected, got)
+		}
+	}
+
+	commandNames := complete("ex")
+	if len(commandNames) != len(Commands(config)) {
+		t.Errorf("expected every command name for the first word, got %v", commandNames)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+type Config struct {
+	Next *string
+	Previous *string
+	Client *pokeapi.Client
+	SavePath string // where save, load and the autosave on exit go
+	Travel Travel // where the player is, encounters happen there
+	Encounter *wildEncounter // the wild pokemon catch is thrown at, nil when there is none
+	Battle *battle.Battle // the fight with the encountered pokemon, nil outside of one
+	BattlerID int // storage ID of the player's pokemon in Battle
+	Storage Storage // the party and PC boxes
+	Profiles *Profiles
+	Stats Stats
+	Output outputFormat // how map, explore, inspect and pokedex print their results
+	Rand randSource // random source for catching
+	Clock func() time.Time // the time catches and evolutions see, time.Now when nil
+
+	// area names seen in map results and travels, offered by tab completion
+	seenAreas map[string]bool
+	// encounter methods of the explored area, offered for encounter
+	areaMethods []string
+}
+
+func (c *Config) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock()
+}
+
+func (c *Config) rememberArea(name string) {
+	if c.seenAreas == nil {
+		c.seenAreas = make(map[string]bool)
+	}
+	c.seenAreas[name] = true
+}
+
+// Stats are the per-profile counters shown by the stats command.
+type Stats struct {
+	CatchAttempts int `json:"catch_attempts"`
+	Caught        int `json:"caught"`
+	Escaped       int `json:"escaped"`
+	AreasExplored int `json:"areas_explored"`
+}
+
+// maxTravelHistory bounds how many stops are kept in the save.
+const maxTravelHistory = 50
+
+// Travel is the location area the player is in and the ones they have
+// travelled to before, oldest first.
+type Travel struct {
+	Location string       `json:"location"`
+	History  []TravelStop `json:"history"`
+}
+
+type TravelStop struct {
+	Area      string    `json:"area"`
+	ArrivedAt time.Time `json:"arrived_at"`
+}
+
+// moveTo makes area the current location and records the stop.
+func (t *Travel) moveTo(area string, at time.Time) {
+	t.Location = area
+	t.History = append(t.History, TravelStop{Area: area, ArrivedAt: at})
+	if len(t.History) > maxTravelHistory {
+		t.History = t.History[len(t.History)-maxTravelHistory:]
+	}
+}
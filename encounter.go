@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// defaultEncounterMethod is tried first when encounter is given no method.
+const defaultEncounterMethod = "walk"
+
+// wildEncounter is the wild Pokemon currently in front of the player, the
+// only one catch can be thrown at. Its individual values and nature are
+// rolled when it appears and stay with it once caught. It's saved, so a
+// one-shot catch can follow a one-shot encounter.
+type wildEncounter struct {
+	Pokemon string       `json:"pokemon"`
+	Level   int          `json:"level"`
+	Method  string       `json:"method"`
+	Area    string       `json:"area"`
+	IVs     battle.Stats `json:"ivs"`
+	Nature  string       `json:"nature"`
+}
+
+// encounterSlot is one row of an area's encounter table. chance is the
+// percentage of encounters with the method that land on this slot.
+type encounterSlot struct {
+	pokemon  string
+	chance   int
+	minLevel int
+	maxLevel int
+}
+
+// encounterMethods lists the methods wild Pokemon can be met with in area,
+// such as walk, surf or old-rod, sorted by name.
+func encounterMethods(area pokeapi.LocationArea) []string {
+	seen := make(map[string]bool)
+	for _, encounter := range area.PokemonEncounters {
+		for _, version := range encounter.VersionDetails {
+			for _, detail := range version.EncounterDetails {
+				seen[detail.Method.Name] = true
+			}
+		}
+	}
+	methods := keys(seen)
+	sort.Strings(methods)
+	return methods
+}
+
+// canAppearIn reports whether pokemon is in area's encounter list at all.
+func canAppearIn(area pokeapi.LocationArea, pokemon string) bool {
+	for _, encounter := range area.PokemonEncounters {
+		if encounter.Pokemon.Name == pokemon {
+			return true
+		}
+	}
+	return false
+}
+
+// encounterTable returns the slots for method in area. The chances of
+// different game versions don't add up to a single table, so the table
+// of the first version that has the method is used.
+func encounterTable(area pokeapi.LocationArea, method string) []encounterSlot {
+	version := ""
+	var slots []encounterSlot
+	for _, encounter := range area.PokemonEncounters {
+		for _, details := range encounter.VersionDetails {
+			for _, detail := range details.EncounterDetails {
+				if detail.Method.Name != method || detail.Chance <= 0 {
+					continue
+				}
+				if version == "" {
+					version = details.Version.Name
+				}
+				if details.Version.Name != version {
+					continue
+				}
+				slots = append(slots, encounterSlot{
+					pokemon:  encounter.Pokemon.Name,
+					chance:   detail.Chance,
+					minLevel: detail.MinLevel,
+					maxLevel: max(detail.MinLevel, detail.MaxLevel),
+				})
+			}
+		}
+	}
+	return slots
+}
+
+// rollEncounter picks a slot weighted by its chance, then a level in the
+// slot's range. It returns false for an empty table.
+func rollEncounter(rng randSource, slots []encounterSlot) (encounterSlot, int, bool) {
+	total := 0
+	for _, slot := range slots {
+		total += slot.chance
+	}
+	if total == 0 {
+		return encounterSlot{}, 0, false
+	}
+
+	roll := rng.Intn(total)
+	for _, slot := range slots {
+		if roll < slot.chance {
+			level := slot.minLevel + rng.Intn(slot.maxLevel-slot.minLevel+1)
+			return slot, level, true
+		}
+		roll -= slot.chance
+	}
+	panic("unreachable: roll is below the total chance")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+)
+
+func TestEncounterTable(t *testing.T) {
+	config := newOfflineConfig(1)
+	area, err := config.Client.GetLocationArea(context.Background(), "canalave-city-area")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if methods := encounterMethods(area); strings.Join(methods, ",") != "good-rod,old-rod,surf" {
+		t.Errorf("unexpected methods %v", methods)
+	}
+	surf := encounterTable(area, "surf")
+	total := 0
+	for _, slot := range surf {
+		total += slot.chance
+		if slot.minLevel != 20 || slot.maxLevel != 30 {
+			t.Errorf("unexpected levels for %s: %d-%d", slot.pokemon, slot.minLevel, slot.maxLevel)
+		}
+	}
+	if len(surf) != 3 || total != 100 {
+		t.Errorf("expected 3 surf slots adding up to 100%%, got %+v", surf)
+	}
+	if walk := encounterTable(area, "walk"); len(walk) != 0 {
+		t.Errorf("expected no walk slots in canalave-city-area, got %+v", walk)
+	}
+}
+
+func TestRollEncounter(t *testing.T) {
+	slots := []encounterSlot{
+		{pokemon: "tentacool", chance: 60, minLevel: 20, maxLevel: 30},
+		{pokemon: "wingull", chance: 30, minLevel: 20, maxLevel: 30},
+		{pokemon: "shellos", chance: 10, minLevel: 20, maxLevel: 30},
+	}
+	cases := []struct {
+		numbers  []int
+		expected string
+		level    int
+	}{
+		{numbers: []int{0, 0}, expected: "tentacool", level: 20},
+		{numbers: []int{59, 10}, expected: "tentacool", level: 30},
+		{numbers: []int{60, 5}, expected: "wingull", level: 25},
+		{numbers: []int{99, 0}, expected: "shellos", level: 20},
+	}
+	for _, c := range cases {
+		slot, level, ok := rollEncounter(&scriptedRand{numbers: c.numbers}, slots)
+		if !ok || slot.pokemon != c.expected || level != c.level {
+			t.Errorf("rolls %v: expected %s level %d, got %s level %d", c.numbers, c.expected, c.level, slot.pokemon, level)
+		}
+	}
+
+	if _, _, ok := rollEncounter(&scriptedRand{}, nil); ok {
+		t.Errorf("expected no encounter from an empty table")
+	}
+}
+
+func TestRollEncounterFollowsChances(t *testing.T) {
+	slots := []encounterSlot{
+		{pokemon: "budew", chance: 40, minLevel: 9, maxLevel: 11},
+		{pokemon: "wurmple", chance: 30, minLevel: 9, maxLevel: 11},
+		{pokemon: "bidoof", chance: 20, minLevel: 10, maxLevel: 12},
+		{pokemon: "pikachu", chance: 10, minLevel: 10, maxLevel: 12},
+	}
+	rng := rand.New(rand.NewSource(1))
+	const trials = 100000
+	counts := make(map[string]int)
+	for range trials {
+		slot, level, _ := rollEncounter(rng, slots)
+		if level < slot.minLevel || level > slot.maxLevel {
+			t.Fatalf("level %d out of range for %s", level, slot.pokemon)
+		}
+		counts[slot.pokemon]++
+	}
+	for _, slot := range slots {
+		got := float64(counts[slot.pokemon]) / trials
+		if math.Abs(got-float64(slot.chance)/100) > 0.01 {
+			t.Errorf("%s: expected a rate near %d%%, got %.3f", slot.pokemon, slot.chance, got)
+		}
+	}
+}
+
+func TestCatchNeedsEncounter(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	run := func(script string, expected int) {
+		t.Helper()
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != expected {
+			t.Fatalf("script %q: expected exit code %d, got %d", script, expected, code)
+		}
+	}
+	run("travel canalave-city-area\ncatch magikarp master-ball\n", exitCommandFailed)
+	run("encounter old-rod\ncatch magikarp master-ball\n", exitOK)
+	run("catch magikarp master-ball\n", exitCommandFailed)
+	// only the throw at the encountered magikarp counts, and it ends the encounter
+	if config.Stats.CatchAttempts != 1 || config.Stats.Caught != 1 {
+		t.Errorf("expected a single successful throw, got %+v", config.Stats)
+	}
+	if config.Encounter != nil {
+		t.Errorf("expected the encounter to end with the catch, got %+v", config.Encounter)
+	}
+	if caught, _ := config.Storage.Find("magikarp"); caught.Location != "canalave-city-area" {
+		t.Errorf("expected magikarp caught in canalave-city-area, got %q", caught.Location)
+	}
+}
+
+func TestCatchWithFullStorage(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	for range partySize + boxCount*boxSize {
+		config.Storage.Add(newTestCaught("bidoof"))
+	}
+	script := `
+travel canalave-city-area
+encounter old-rod
+catch magikarp master-ball
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitCommandFailed {
+		t.Errorf("expected exit code %d, got %d", exitCommandFailed, code)
+	}
+	// the ball is never thrown, so the encounter goes on
+	if config.Stats.CatchAttempts != 0 || config.Stats.Caught != 0 {
+		t.Errorf("expected no throw with full storage, got %+v", config.Stats)
+	}
+	if config.Encounter == nil || pokedex.HasCaught("magikarp") {
+		t.Errorf("expected magikarp to still be wild, got %+v", config.Encounter)
+	}
+}
+
+func TestCatchKeepsWhatWasEncountered(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	script := `
+travel canalave-city-area
+encounter old-rod
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	wild := *config.Encounter
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader("catch magikarp master-ball\n"), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+
+	caught, err := config.Storage.Find("magikarp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caught.Level != wild.Level || caught.IVs != wild.IVs || caught.Nature != wild.Nature {
+		t.Errorf("expected the caught magikarp to be the one encountered, %+v, got %+v", wild, caught)
+	}
+	// magikarp grows slowly
+	if caught.GrowthRate != battle.Slow || caught.Experience != battle.ExperienceAt(battle.Slow, wild.Level) {
+		t.Errorf("expected the experience of a slow level %d, got %d (%s)", wild.Level, caught.Experience, caught.GrowthRate)
+	}
+	if caught.EVs != (battle.Stats{}) {
+		t.Errorf("expected no effort values before any battle, got %+v", caught.EVs)
+	}
+}
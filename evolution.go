@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// evolution triggers, named as PokeAPI's evolution-trigger resources are
+const (
+	triggerLevelUp = "level-up"
+	triggerUseItem = "use-item"
+	triggerTrade   = "trade"
+)
+
+// maxFriendship is as friendly as a Pokemon gets.
+const maxFriendship = 255
+
+// evolutionTrigger is what just happened to a Pokemon that might make it
+// evolve: it levelled up, had item used on it or was traded, at now.
+type evolutionTrigger struct {
+	name string
+	item string
+	now  time.Time
+}
+
+// timeOfDay is "night" from 20:00 to 03:59 and "day" otherwise, the
+// values PokeAPI's time_of_day condition takes.
+func timeOfDay(t time.Time) string {
+	if hour := t.Hour(); hour >= 20 || hour < 4 {
+		return "night"
+	}
+	return "day"
+}
+
+// findLink returns the link of species in the chain starting at link.
+func findLink(link pokeapi.ChainLink, species string) (pokeapi.ChainLink, bool) {
+	if link.Species.Name == species {
+		return link, true
+	}
+	for _, next := range link.EvolvesTo {
+		if found, ok := findLink(next, species); ok {
+			return found, true
+		}
+	}
+	return pokeapi.ChainLink{}, false
+}
+
+// nextEvolution returns the species p, with stats, evolves into when
+// trigger happens, the first in chain order whose conditions all hold.
+func nextEvolution(chain pokeapi.EvolutionChain, p CaughtPokemon, stats battle.Stats, trigger evolutionTrigger) (string, bool) {
+	link, ok := findLink(chain.Chain, p.Species)
+	if !ok {
+		return "", false
+	}
+	for _, next := range link.EvolvesTo {
+		for _, detail := range next.EvolutionDetails {
+			if meetsEvolution(detail, p, stats, trigger) {
+				return next.Species.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// meetsEvolution reports whether every condition of detail holds. The
+// game doesn't keep track of held items, known moves, the party, gender,
+// beauty, affection or the weather, so conditions on those are never met.
+func meetsEvolution(detail pokeapi.EvolutionDetail, p CaughtPokemon, stats battle.Stats, trigger evolutionTrigger) bool {
+	if detail.Trigger.Name != trigger.name {
+		return false
+	}
+	if detail.Item != nil && detail.Item.Name != trigger.item {
+		return false
+	}
+	if detail.MinLevel != nil && p.Level < *detail.MinLevel {
+		return false
+	}
+	if detail.MinHappiness != nil && p.Friendship < *detail.MinHappiness {
+		return false
+	}
+	if detail.TimeOfDay != "" && detail.TimeOfDay != timeOfDay(trigger.now) {
+		return false
+	}
+	if detail.RelativePhysicalStats != nil {
+		relative := 0
+		if stats.Attack > stats.Defense {
+			relative = 1
+		} else if stats.Attack < stats.Defense {
+			relative = -1
+		}
+		if relative != *detail.RelativePhysicalStats {
+			return false
+		}
+	}
+	return detail.HeldItem == nil && detail.KnownMove == nil && detail.KnownMoveType == nil &&
+		detail.Location == nil && detail.PartySpecies == nil && detail.PartyType == nil &&
+		detail.TradeSpecies == nil && detail.Gender == nil && detail.MinBeauty == nil &&
+		detail.MinAffection == nil && !detail.NeedsOverworldRain && !detail.TurnUpsideDown
+}
+
+// evolutionUnavailable stands in for the chain when it can't be fetched.
+const evolutionUnavailable = "unavailable"
+
+// chainText writes a chain as "pichu -> pikachu -> raichu", branches in
+// brackets: "wurmple -> (silcoon -> beautifly | cascoon -> dustox)".
+func chainText(link pokeapi.ChainLink) string {
+	switch len(link.EvolvesTo) {
+	case 0:
+		return link.Species.Name
+	case 1:
+		return link.Species.Name + " -> " + chainText(link.EvolvesTo[0])
+	}
+	branches := make([]string, len(link.EvolvesTo))
+	for i, next := range link.EvolvesTo {
+		branches[i] = chainText(next)
+	}
+	return link.Species.Name + " -> (" + strings.Join(branches, " | ") + ")"
+}
+
+// evolutionChain fetches the chain speciesName belongs to.
+func evolutionChain(ctx context.Context, client *pokeapi.Client, speciesName string) (pokeapi.EvolutionChain, error) {
+	species, err := client.GetPokemonSpecies(ctx, speciesName)
+	if err != nil {
+		return pokeapi.EvolutionChain{}, err
+	}
+	return client.GetEvolutionChain(ctx, species.EvolutionChain.URL)
+}
+
+// tryEvolve evolves p in place when trigger makes it evolve, keeping its ID
+// and everything it has earned, and registers the new species in the
+// Pokedex. It reports whether p evolved.
+func tryEvolve(ctx context.Context, config *Config, pokedex *Pokedex, p *CaughtPokemon, trigger evolutionTrigger) (bool, error) {
+	chain, err := evolutionChain(ctx, config.Client, p.Species)
+	if err != nil {
+		return false, err
+	}
+	current, err := config.Client.GetPokemon(ctx, p.Name)
+	if err != nil {
+		return false, err
+	}
+	target, ok := nextEvolution(chain, *p, p.ActualStats(current), trigger)
+	if !ok {
+		return false, nil
+	}
+	species, err := config.Client.GetPokemonSpecies(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	evolved, err := config.Client.GetPokemon(ctx, defaultVariety(species))
+	if err != nil {
+		return false, err
+	}
+
+	before := p.Name
+	p.Name, p.Species = evolved.Name, evolved.Species.Name
+	pokedex.MarkCaught(evolved.Name)
+	if !config.Output.structured() {
+		fmt.Println("What? " + before + " is evolving!")
+		fmt.Println("Congratulations! Your " + before + " evolved into " + evolved.Name + "!")
+	}
+	return true, nil
+}
+
+// defaultVariety is the name of the Pokemon a species usually appears as.
+func defaultVariety(species pokeapi.PokemonSpecies) string {
+	for _, variety := range species.Varieties {
+		if variety.IsDefault {
+			return variety.Pokemon.Name
+		}
+	}
+	return species.Name
+}
+
+func commandEvolve(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 && len(commandWords) != 3 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 or 2 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	found, err := config.Storage.Find(commandWords[1])
+	if err != nil {
+		return reportFailure(err)
+	}
+	if err := checkNotBattling(config, commandWords[1]); err != nil {
+		return reportFailure(err)
+	}
+
+	trigger := evolutionTrigger{name: triggerLevelUp, now: config.now()}
+	if len(commandWords) == 3 && commandWords[2] == triggerTrade {
+		trigger.name = triggerTrade
+	} else if len(commandWords) == 3 {
+		trigger.name, trigger.item = triggerUseItem, commandWords[2]
+	}
+
+	pokemon := config.Storage.Get(found.ID)
+	before := pokemon.Name
+	evolved, err := tryEvolve(ctx, config, pokedex, pokemon, trigger)
+	if err != nil {
+		return reportAPIError(err, "there is no evolution data for " + before)
+	}
+	if config.Output.structured() {
+		return printRecord(os.Stdout, config.Output, Record{
+			{"id", pokemon.ID},
+			{"name", pokemon.Name},
+			{"evolved_from", before},
+			{"evolved", evolved},
+		})
+	}
+	if !evolved {
+		switch trigger.name {
+		case triggerUseItem:
+			fmt.Println("nothing happened, " + before + " doesn't evolve with a " + trigger.item)
+		case triggerTrade:
+			fmt.Println(before + " doesn't evolve by being traded")
+		default:
+			fmt.Println(before + " isn't ready to evolve")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// newTestOwned is a caught Pokemon of species with just what evolving
+// looks at.
+func newTestOwned(species string, level, friendship int) CaughtPokemon {
+	return CaughtPokemon{Name: species, Species: species, Level: level, Friendship: friendship}
+}
+
+func TestNextEvolution(t *testing.T) {
+	config := newOfflineConfig(1)
+	noon := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		pokemon  CaughtPokemon
+		trigger  evolutionTrigger
+		expected string
+	}{
+		{"too low a level", newTestOwned("bidoof", 14, 70), evolutionTrigger{triggerLevelUp, "", noon}, ""},
+		{"level", newTestOwned("bidoof", 15, 70), evolutionTrigger{triggerLevelUp, "", noon}, "bibarel"},
+		{"item", newTestOwned("pikachu", 5, 70), evolutionTrigger{triggerUseItem, "thunder-stone", noon}, "raichu"},
+		{"wrong item", newTestOwned("pikachu", 5, 70), evolutionTrigger{triggerUseItem, "fire-stone", noon}, ""},
+		{"item needed", newTestOwned("pikachu", 50, 255), evolutionTrigger{triggerLevelUp, "", noon}, ""},
+		{"friendship", newTestOwned("budew", 5, 220), evolutionTrigger{triggerLevelUp, "", noon}, "roselia"},
+		{"not friendly enough", newTestOwned("budew", 5, 219), evolutionTrigger{triggerLevelUp, "", noon}, ""},
+		{"only by day", newTestOwned("budew", 5, 220), evolutionTrigger{triggerLevelUp, "", midnight}, ""},
+		{"first branch", newTestOwned("wurmple", 7, 70), evolutionTrigger{triggerLevelUp, "", noon}, "silcoon"},
+		{"last stage", newTestOwned("raichu", 100, 255), evolutionTrigger{triggerUseItem, "thunder-stone", noon}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chain, err := evolutionChain(context.Background(), config.Client, c.pokemon.Species)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := nextEvolution(chain, c.pokemon, battle.Stats{}, c.trigger)
+			if got != c.expected || ok != (c.expected != "") {
+				t.Errorf("expected %q, got %q", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestTradeEvolution(t *testing.T) {
+	trade := pokeapi.EvolutionDetail{Trigger: pokeapi.NamedResource{Name: triggerTrade}}
+	withItem := trade
+	withItem.HeldItem = &pokeapi.NamedResource{Name: "metal-coat"}
+	chain := pokeapi.EvolutionChain{Chain: pokeapi.ChainLink{
+		Species: pokeapi.NamedResource{Name: "kadabra"},
+		EvolvesTo: []pokeapi.ChainLink{{
+			Species:          pokeapi.NamedResource{Name: "alakazam"},
+			EvolutionDetails: []pokeapi.EvolutionDetail{trade},
+		}},
+	}}
+	kadabra := newTestOwned("kadabra", 16, 70)
+	if got, _ := nextEvolution(chain, kadabra, battle.Stats{}, evolutionTrigger{name: triggerTrade}); got != "alakazam" {
+		t.Errorf("expected kadabra to evolve when traded, got %q", got)
+	}
+	if got, ok := nextEvolution(chain, kadabra, battle.Stats{}, evolutionTrigger{name: triggerLevelUp}); ok {
+		t.Errorf("expected kadabra not to evolve by levelling, got %q", got)
+	}
+	// held items aren't tracked, so evolutions needing one never happen
+	chain.Chain.EvolvesTo[0].EvolutionDetails[0] = withItem
+	if got, ok := nextEvolution(chain, kadabra, battle.Stats{}, evolutionTrigger{name: triggerTrade}); ok {
+		t.Errorf("expected no evolution needing a held item, got %q", got)
+	}
+}
+
+func TestChainText(t *testing.T) {
+	config := newOfflineConfig(1)
+	chain, err := evolutionChain(context.Background(), config.Client, "wurmple")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "wurmple -> (silcoon -> beautifly | cascoon -> dustox)"
+	if got := chainText(chain.Chain); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestEvolveWithItem(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	pikachu, err := config.Client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caught, _, _ := config.Storage.Add(CaughtPokemon{Name: pikachu.Name, Species: pikachu.Species.Name, Level: 12, Friendship: 70})
+	script := `
+evolve pikachu
+evolve pikachu fire-stone
+evolve pikachu thunder-stone
+inspect raichu
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	evolved, err := config.Storage.Find("#1")
+	if err != nil || evolved.Name != "raichu" || evolved.ID != caught.ID || evolved.Level != 12 {
+		t.Errorf("expected pikachu #%d to become a level 12 raichu, got %+v (%v)", caught.ID, evolved, err)
+	}
+	if !pokedex.HasCaught("raichu") {
+		t.Errorf("expected raichu to be registered in the pokedex")
+	}
+}
+
+func TestEvolveOnLevelUp(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	bidoof, err := config.Client.GetPokemon(context.Background(), "bidoof")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 5 experience short of level 15, when bidoof evolves
+	config.Storage.Add(CaughtPokemon{Name: bidoof.Name, Species: bidoof.Species.Name, Level: 14, Experience: 3370, GrowthRate: "medium", Friendship: 70})
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader("travel canalave-city-area\n"), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	config.Encounter = &wildEncounter{Pokemon: "wingull", Level: 2, Method: "surf", Area: "canalave-city-area"}
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader("battle bidoof\n"), "test"); code != exitOK || config.Battle == nil {
+		t.Fatalf("expected a battle, got exit code %d", code)
+	}
+	for turn := 0; config.Battle != nil; turn++ {
+		if turn == 20 {
+			t.Fatalf("battle still going after %d turns", turn)
+		}
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader("fight tackle\n"), "test"); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d", exitOK, code)
+		}
+	}
+
+	evolved, _ := config.Storage.Find("1")
+	if evolved.Name != "bibarel" || evolved.Level != 15 || evolved.Friendship != 75 {
+		t.Errorf("expected a level 15 bibarel, got %s at level %d with %d friendship", evolved.Name, evolved.Level, evolved.Friendship)
+	}
+	if !pokedex.HasCaught("bibarel") {
+		t.Errorf("expected bibarel to be registered in the pokedex")
+	}
+}
+
+func TestInspectWithoutEvolutionChain(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", Level: 12, Friendship: 70})
+	// a bundle with the pokemon but without their species and evolution chains
+	dir := t.TempDir()
+	if err := os.CopyFS(filepath.Join(dir, "pokemon"), os.DirFS("testdata/bundle/pokemon")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.Client = pokeapi.NewClient(pokeapi.Options{
+		HTTPClient: &http.Client{Transport: &pokeapi.OfflineTransport{Dir: dir}},
+	})
+	for _, output := range []string{"text", "json"} {
+		script := "set output " + output + "\ninspect pikachu\n"
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+			t.Errorf("%s: expected exit code %d, got %d", output, exitOK, code)
+		}
+	}
+}
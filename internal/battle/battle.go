@@ -0,0 +1,262 @@
+// Package battle runs turn-based fights between two Pokemon with the
+// mainline damage formula. It knows nothing about PokeAPI, callers build
+// the Pokemon, moves and type chart from its responses.
+package battle
+
+import (
+	"errors"
+	"sort"
+)
+
+// Rand is where every random decision comes from, a seeded *rand.Rand
+// makes a whole battle reproducible.
+type Rand interface {
+	Intn(n int) int
+}
+
+type Stats struct {
+	HP             int `json:"hp"`
+	Attack         int `json:"attack"`
+	Defense        int `json:"defense"`
+	SpecialAttack  int `json:"special_attack"`
+	SpecialDefense int `json:"special_defense"`
+	Speed          int `json:"speed"`
+}
+
+// StatsAt turns base stats into the stats of a Pokemon at level, without
+// individual or effort values.
+func StatsAt(base Stats, level int) Stats {
+	return CalcStats(base, Stats{}, Stats{}, level, Nature{})
+}
+
+type Category string
+
+const (
+	Physical Category = "physical"
+	Special  Category = "special"
+	Status   Category = "status"
+)
+
+type Move struct {
+	Name     string
+	Type     string
+	Category Category
+	Power    int // 0 for moves that don't deal damage
+	Accuracy int // in percent, 0 for moves that never miss
+	Priority int
+	CritRate int // critical hit stage, 1 for moves like slash
+}
+
+// Struggle is used by a Pokemon that has no moves at all.
+var Struggle = Move{Name: "struggle", Category: Physical, Power: 50}
+
+type Pokemon struct {
+	Name  string
+	Level int
+	Types []string
+	Stats Stats // at Level, Stats.HP is the maximum
+	HP    int   // remaining
+	Moves []Move
+}
+
+// NewPokemon returns a Pokemon at full health.
+func NewPokemon(name string, level int, types []string, stats Stats, moves []Move) *Pokemon {
+	return &Pokemon{
+		Name:  name,
+		Level: level,
+		Types: types,
+		Stats: stats,
+		HP:    stats.HP,
+		Moves: moves,
+	}
+}
+
+func (p *Pokemon) Fainted() bool {
+	return p.HP <= 0
+}
+
+// Move returns the move called name, Struggle stands in when p knows none.
+func (p *Pokemon) Move(name string) (Move, bool) {
+	if len(p.Moves) == 0 && name == Struggle.Name {
+		return Struggle, true
+	}
+	for _, move := range p.Moves {
+		if move.Name == name {
+			return move, true
+		}
+	}
+	return Move{}, false
+}
+
+// MoveNames lists the moves p can use, for completion and help.
+func (p *Pokemon) MoveNames() []string {
+	if len(p.Moves) == 0 {
+		return []string{Struggle.Name}
+	}
+	names := make([]string, len(p.Moves))
+	for i, move := range p.Moves {
+		names[i] = move.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TypeChart maps an attacking type to the multiplier against each
+// defending type, pairs that aren't listed are 1.
+type TypeChart map[string]map[string]float64
+
+// Effectiveness multiplies the attacking type's multipliers against every
+// defending type, so a dual type Pokemon can take 4x or 0.25x damage.
+func (c TypeChart) Effectiveness(attacking string, defending []string) float64 {
+	multiplier := 1.0
+	for _, defendingType := range defending {
+		if m, ok := c[attacking][defendingType]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// Hit is what happened when one Pokemon used a move on the other.
+type Hit struct {
+	Attacker      string
+	Defender      string
+	Move          string
+	Missed        bool
+	Damage        int
+	Critical      bool
+	Effectiveness float64 // 1 unless the move deals damage
+	DefenderHP    int     // what the defender has left
+	Fainted       bool    // the defender fainted from it
+}
+
+// critChances[stage] is the 1 in n chance of a critical hit
+var critChances = []int{24, 8, 2, 1}
+
+// Attack has attacker use move on defender and takes the damage off the
+// defender's HP. Random numbers are drawn in a fixed order: accuracy (when
+// the move can miss), then for damaging moves the critical hit and the
+// random factor.
+func Attack(rng Rand, chart TypeChart, attacker, defender *Pokemon, move Move) Hit {
+	hit := Hit{Attacker: attacker.Name, Defender: defender.Name, Move: move.Name, Effectiveness: 1, DefenderHP: defender.HP}
+	if move.Accuracy > 0 && rng.Intn(100) >= move.Accuracy {
+		hit.Missed = true
+		return hit
+	}
+	if move.Category == Status || move.Power == 0 {
+		return hit
+	}
+
+	hit.Effectiveness = chart.Effectiveness(move.Type, defender.Types)
+	stage := min(max(move.CritRate, 0), len(critChances)-1)
+	hit.Critical = rng.Intn(critChances[stage]) == 0
+	random := 85 + rng.Intn(16)
+	hit.Damage = damage(attacker, defender, move, hit.Effectiveness, hit.Critical, random)
+
+	defender.HP = max(0, defender.HP-hit.Damage)
+	hit.DefenderHP = defender.HP
+	hit.Fainted = defender.Fainted()
+	return hit
+}
+
+// damage is the generation V+ formula:
+// ((2*level/5 + 2) * power * attack/defense / 50 + 2) * modifiers
+// with the modifiers applied one at a time, rounding down after each.
+// random is the random factor in percent, 85 to 100.
+func damage(attacker, defender *Pokemon, move Move, effectiveness float64, critical bool, random int) int {
+	if effectiveness == 0 {
+		return 0
+	}
+	attack, defense := attacker.Stats.Attack, defender.Stats.Defense
+	if move.Category == Special {
+		attack, defense = attacker.Stats.SpecialAttack, defender.Stats.SpecialDefense
+	}
+	defense = max(defense, 1)
+
+	base := (2*attacker.Level/5+2)*move.Power*attack/defense/50 + 2
+	if critical {
+		base = base * 3 / 2
+	}
+	base = base * random / 100
+	for _, attackerType := range attacker.Types {
+		if attackerType == move.Type {
+			base = base * 3 / 2 // same type attack bonus
+			break
+		}
+	}
+	return max(1, int(float64(base)*effectiveness))
+}
+
+// Battle is a fight between the player's Pokemon and a wild one.
+type Battle struct {
+	Player *Pokemon
+	Wild   *Pokemon
+	Chart  TypeChart
+	Turns  int
+
+	rng Rand
+}
+
+var (
+	ErrOver        = errors.New("the battle is over")
+	ErrUnknownMove = errors.New("unknown move")
+)
+
+func New(player, wild *Pokemon, chart TypeChart, rng Rand) *Battle {
+	return &Battle{Player: player, Wild: wild, Chart: chart, rng: rng}
+}
+
+// Over reports whether either side has fainted.
+func (b *Battle) Over() bool {
+	return b.Player.Fainted() || b.Wild.Fainted()
+}
+
+// Turn plays one round: the player uses moveName and the wild Pokemon a
+// random move of its own. The higher priority move goes first, then the
+// faster Pokemon, and a speed tie is a coin flip. A Pokemon that faints
+// doesn't get to move.
+func (b *Battle) Turn(moveName string) ([]Hit, error) {
+	if b.Over() {
+		return nil, ErrOver
+	}
+	playerMove, ok := b.Player.Move(moveName)
+	if !ok {
+		return nil, ErrUnknownMove
+	}
+	wildMove := Struggle
+	if len(b.Wild.Moves) > 0 {
+		wildMove = b.Wild.Moves[b.rng.Intn(len(b.Wild.Moves))]
+	}
+
+	type action struct {
+		attacker, defender *Pokemon
+		move               Move
+	}
+	actions := []action{
+		{b.Player, b.Wild, playerMove},
+		{b.Wild, b.Player, wildMove},
+	}
+	if b.wildMovesFirst(playerMove, wildMove) {
+		actions[0], actions[1] = actions[1], actions[0]
+	}
+
+	b.Turns++
+	var hits []Hit
+	for _, a := range actions {
+		if a.attacker.Fainted() {
+			break
+		}
+		hits = append(hits, Attack(b.rng, b.Chart, a.attacker, a.defender, a.move))
+	}
+	return hits, nil
+}
+
+func (b *Battle) wildMovesFirst(playerMove, wildMove Move) bool {
+	if playerMove.Priority != wildMove.Priority {
+		return wildMove.Priority > playerMove.Priority
+	}
+	if b.Player.Stats.Speed != b.Wild.Stats.Speed {
+		return b.Wild.Stats.Speed > b.Player.Stats.Speed
+	}
+	return b.rng.Intn(2) == 1
+}
@@ -0,0 +1,225 @@
+package battle
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// scriptedRand returns the given numbers in order.
+type scriptedRand struct {
+	numbers []int
+}
+
+func (r *scriptedRand) Intn(n int) int {
+	next := r.numbers[0]
+	r.numbers = r.numbers[1:]
+	return next
+}
+
+var testChart = TypeChart{
+	"electric": {"water": 2, "flying": 2, "ground": 0, "electric": 0.5},
+	"water":    {"water": 0.5, "fire": 2},
+	"ice":      {"dragon": 2, "ground": 2},
+}
+
+func TestStatsAt(t *testing.T) {
+	pikachu := Stats{HP: 35, Attack: 55, Defense: 40, SpecialAttack: 50, SpecialDefense: 50, Speed: 90}
+	expected := Stats{HP: 95, Attack: 60, Defense: 45, SpecialAttack: 55, SpecialDefense: 55, Speed: 95}
+	if got := StatsAt(pikachu, 50); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+// the worked example from Bulbapedia: a level 78 Adamant Garchomp
+func TestCalcStats(t *testing.T) {
+	base := Stats{HP: 108, Attack: 130, Defense: 95, SpecialAttack: 80, SpecialDefense: 85, Speed: 102}
+	ivs := Stats{HP: 24, Attack: 12, Defense: 30, SpecialAttack: 16, SpecialDefense: 23, Speed: 5}
+	evs := Stats{HP: 74, Attack: 190, Defense: 91, SpecialAttack: 48, SpecialDefense: 84, Speed: 23}
+	adamant, _ := NatureByName("adamant")
+	expected := Stats{HP: 289, Attack: 278, Defense: 193, SpecialAttack: 135, SpecialDefense: 171, Speed: 171}
+	if got := CalcStats(base, ivs, evs, 78, adamant); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestAddEffort(t *testing.T) {
+	evs := Stats{Attack: 250, Speed: 4}
+	if got := evs.AddEffort(Stats{Attack: 3, Speed: 2}); got != (Stats{Attack: MaxEV, Speed: 6}) {
+		t.Errorf("expected attack to stop at %d, got %+v", MaxEV, got)
+	}
+	full := Stats{HP: MaxEV, Attack: MaxEV, Defense: 4}
+	if got := full.AddEffort(Stats{Speed: 3}); got.Total() != MaxTotalEV || got.Speed != 2 {
+		t.Errorf("expected the total to stop at %d, got %+v", MaxTotalEV, got)
+	}
+}
+
+func TestExperience(t *testing.T) {
+	cases := []struct {
+		rate     GrowthRate
+		level    int
+		expected int
+	}{
+		{Medium, 100, 1000000},
+		{Slow, 100, 1250000},
+		{Fast, 100, 800000},
+		{MediumSlow, 2, 9},
+		{MediumSlow, 100, 1059860},
+		{SlowThenVeryFast, 50, 125000},
+		{SlowThenVeryFast, 100, 600000},
+		{FastThenVerySlow, 15, 1957},
+		{FastThenVerySlow, 100, 1640000},
+		{Medium, 1, 0},
+	}
+	for _, c := range cases {
+		if got := ExperienceAt(c.rate, c.level); got != c.expected {
+			t.Errorf("%s at level %d: expected %d, got %d", c.rate, c.level, c.expected, got)
+		}
+		if got := LevelFor(c.rate, c.expected); got != c.level {
+			t.Errorf("%s with %d experience: expected level %d, got %d", c.rate, c.expected, c.level, got)
+		}
+	}
+	if got := LevelFor(Medium, 999); got != 9 {
+		t.Errorf("expected level 9 just short of 1000 experience, got %d", got)
+	}
+}
+
+func TestEffectiveness(t *testing.T) {
+	cases := []struct {
+		attacking string
+		defending []string
+		expected  float64
+	}{
+		{"electric", []string{"water", "flying"}, 4},
+		{"electric", []string{"ground", "flying"}, 0},
+		{"water", []string{"water", "poison"}, 0.5},
+		{"normal", []string{"water"}, 1},
+	}
+	for _, c := range cases {
+		if got := testChart.Effectiveness(c.attacking, c.defending); got != c.expected {
+			t.Errorf("%s against %v: expected %v, got %v", c.attacking, c.defending, c.expected, got)
+		}
+	}
+}
+
+// the worked example from Bulbapedia: a level 75 Glaceon's Ice Fang
+// against a Garchomp does 168 to 196 damage
+func TestDamageFormula(t *testing.T) {
+	glaceon := NewPokemon("glaceon", 75, []string{"ice"}, Stats{HP: 200, Attack: 123}, nil)
+	garchomp := NewPokemon("garchomp", 65, []string{"dragon", "ground"}, Stats{HP: 200, Defense: 163}, nil)
+	iceFang := Move{Name: "ice-fang", Type: "ice", Category: Physical, Power: 65}
+
+	effectiveness := testChart.Effectiveness(iceFang.Type, garchomp.Types)
+	if got := damage(glaceon, garchomp, iceFang, effectiveness, false, 85); got != 168 {
+		t.Errorf("expected 168 with the lowest roll, got %d", got)
+	}
+	if got := damage(glaceon, garchomp, iceFang, effectiveness, false, 100); got != 196 {
+		t.Errorf("expected 196 with the highest roll, got %d", got)
+	}
+	if got := damage(glaceon, garchomp, iceFang, effectiveness, true, 100); got != 292 {
+		t.Errorf("expected 292 for a critical hit, got %d", got)
+	}
+}
+
+func TestAttack(t *testing.T) {
+	newPikachu := func() *Pokemon {
+		return NewPokemon("pikachu", 10, []string{"electric"}, Stats{HP: 30, SpecialAttack: 20, Speed: 30}, nil)
+	}
+	newWingull := func() *Pokemon {
+		return NewPokemon("wingull", 10, []string{"water", "flying"}, Stats{HP: 30, SpecialDefense: 15, Speed: 20}, nil)
+	}
+	thunderShock := Move{Name: "thunder-shock", Type: "electric", Category: Special, Power: 40, Accuracy: 100}
+
+	// accuracy roll 0, no critical hit (1 of 24), top random roll
+	wingull := newWingull()
+	hit := Attack(&scriptedRand{numbers: []int{0, 1, 15}}, testChart, newPikachu(), wingull, thunderShock)
+	// (6*40*20/15/50 + 2) = 8, *1.5 STAB = 12, *4 = 48
+	if hit.Missed || hit.Critical || hit.Damage != 48 || hit.Effectiveness != 4 {
+		t.Errorf("unexpected hit %+v", hit)
+	}
+	if wingull.HP != 0 || !hit.Fainted {
+		t.Errorf("expected wingull to faint, %d HP left", wingull.HP)
+	}
+
+	hit = Attack(&scriptedRand{numbers: []int{55}}, testChart, newPikachu(), newWingull(), Move{Name: "supersonic", Category: Status, Accuracy: 55})
+	if !hit.Missed {
+		t.Errorf("expected a roll of 55 to miss a 55%% move, got %+v", hit)
+	}
+
+	wingull = newWingull()
+	hit = Attack(&scriptedRand{}, testChart, newPikachu(), wingull, Move{Name: "growl", Category: Status})
+	if hit.Missed || hit.Damage != 0 || wingull.HP != 30 {
+		t.Errorf("expected growl to land without damage, got %+v", hit)
+	}
+}
+
+func TestTurnOrder(t *testing.T) {
+	quickAttack := Move{Name: "quick-attack", Type: "normal", Category: Physical, Power: 40, Priority: 1}
+	tackle := Move{Name: "tackle", Type: "normal", Category: Physical, Power: 40}
+	newBattle := func(numbers ...int) *Battle {
+		slow := NewPokemon("bidoof", 5, []string{"normal"}, Stats{HP: 20, Attack: 10, Defense: 10, Speed: 5}, []Move{tackle, quickAttack})
+		fast := NewPokemon("pikachu", 5, []string{"electric"}, Stats{HP: 20, Attack: 10, Defense: 10, Speed: 50}, []Move{tackle})
+		return New(slow, fast, testChart, &scriptedRand{numbers: numbers})
+	}
+
+	// wild move choice, then crit and random roll for each hit
+	hits, err := newBattle(0, 1, 0, 1, 0).Turn("tackle")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Attacker != "pikachu" {
+		t.Errorf("expected the faster pikachu to move first, got %+v", hits)
+	}
+	hits, _ = newBattle(0, 1, 0, 1, 0).Turn("quick-attack")
+	if len(hits) != 2 || hits[0].Attacker != "bidoof" {
+		t.Errorf("expected quick-attack to go first, got %+v", hits)
+	}
+
+	if _, err := newBattle().Turn("thunderbolt"); err != ErrUnknownMove {
+		t.Errorf("expected ErrUnknownMove, got %v", err)
+	}
+}
+
+func TestFaintedPokemonDoesNotMove(t *testing.T) {
+	tackle := Move{Name: "tackle", Type: "normal", Category: Physical, Power: 40}
+	strong := NewPokemon("bidoof", 50, []string{"normal"}, Stats{HP: 100, Attack: 100, Defense: 50, Speed: 50}, []Move{tackle})
+	weak := NewPokemon("wurmple", 2, []string{"bug"}, Stats{HP: 5, Attack: 5, Defense: 5, Speed: 5}, []Move{tackle})
+	b := New(strong, weak, testChart, &scriptedRand{numbers: []int{0, 1, 0}})
+
+	hits, err := b.Turn("tackle")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hits) != 1 || !hits[0].Fainted || !b.Over() {
+		t.Errorf("expected a single knockout hit, got %+v", hits)
+	}
+	if _, err := b.Turn("tackle"); err != ErrOver {
+		t.Errorf("expected ErrOver after the battle ended, got %v", err)
+	}
+}
+
+func TestSeededBattlesRepeat(t *testing.T) {
+	play := func(seed int64) [][]Hit {
+		moves := []Move{
+			{Name: "thunder-shock", Type: "electric", Category: Special, Power: 40, Accuracy: 100},
+			{Name: "growl", Category: Status, Accuracy: 100},
+		}
+		player := NewPokemon("pikachu", 12, []string{"electric"}, StatsAt(Stats{HP: 35, Attack: 55, Defense: 40, SpecialAttack: 50, SpecialDefense: 50, Speed: 90}, 12), moves)
+		wild := NewPokemon("shellos", 12, []string{"water"}, StatsAt(Stats{HP: 76, Attack: 48, Defense: 48, SpecialAttack: 57, SpecialDefense: 62, Speed: 34}, 12),
+			[]Move{{Name: "water-pulse", Type: "water", Category: Special, Power: 60, Accuracy: 100}})
+		b := New(player, wild, testChart, rand.New(rand.NewSource(seed)))
+		var turns [][]Hit
+		for !b.Over() {
+			hits, err := b.Turn("thunder-shock")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			turns = append(turns, hits)
+		}
+		return turns
+	}
+	first, second := play(7), play(7)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same seed to replay the same battle:\n%+v\n%+v", first, second)
+	}
+}
@@ -0,0 +1,67 @@
+package battle
+
+// GrowthRate is how much experience a species needs per level, named as
+// PokeAPI's growth-rate resources are.
+type GrowthRate string
+
+const (
+	Slow             GrowthRate = "slow"
+	Medium           GrowthRate = "medium"
+	Fast             GrowthRate = "fast"
+	MediumSlow       GrowthRate = "medium-slow"
+	SlowThenVeryFast GrowthRate = "slow-then-very-fast"
+	FastThenVerySlow GrowthRate = "fast-then-very-slow"
+)
+
+// ExperienceAt returns the total experience a Pokemon needs to reach
+// level. A growth rate it doesn't know is treated as Medium, the most
+// common one.
+func ExperienceAt(rate GrowthRate, level int) int {
+	if level <= 1 {
+		return 0
+	}
+	n := min(level, MaxLevel)
+	cube := n * n * n
+	switch rate {
+	case Slow:
+		return 5 * cube / 4
+	case Fast:
+		return 4 * cube / 5
+	case MediumSlow:
+		return 6*cube/5 - 15*n*n + 100*n - 140
+	case SlowThenVeryFast:
+		switch {
+		case n < 50:
+			return cube * (100 - n) / 50
+		case n < 68:
+			return cube * (150 - n) / 100
+		case n < 98:
+			return cube * ((1911 - 10*n) / 3) / 500
+		}
+		return cube * (160 - n) / 100
+	case FastThenVerySlow:
+		switch {
+		case n < 15:
+			return cube * ((n+1)/3 + 24) / 50
+		case n < 36:
+			return cube * (n + 14) / 50
+		}
+		return cube * (n/2 + 32) / 50
+	}
+	return cube
+}
+
+// LevelFor returns the level a Pokemon with experience has reached.
+func LevelFor(rate GrowthRate, experience int) int {
+	level := 1
+	for level < MaxLevel && ExperienceAt(rate, level+1) <= experience {
+		level++
+	}
+	return level
+}
+
+// ExperienceYield is the experience for defeating a wild Pokemon with
+// baseExperience at level.
+func ExperienceYield(baseExperience, level int) int {
+	return max(1, baseExperience*level/7)
+}
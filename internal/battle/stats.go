@@ -0,0 +1,146 @@
+package battle
+
+const (
+	MaxIV      = 31
+	MaxEV      = 252 // per stat
+	MaxTotalEV = 510
+	MaxLevel   = 100
+)
+
+// StatNames are the PokeAPI names of the six stats, in Stats' order.
+var StatNames = []string{"hp", "attack", "defense", "special-attack", "special-defense", "speed"}
+
+// Get returns the stat PokeAPI calls name, 0 for a name it doesn't know.
+func (s Stats) Get(name string) int {
+	if field := s.field(name); field != nil {
+		return *field
+	}
+	return 0
+}
+
+// Set changes the stat PokeAPI calls name, a name it doesn't know is
+// ignored.
+func (s *Stats) Set(name string, value int) {
+	if field := s.field(name); field != nil {
+		*field = value
+	}
+}
+
+func (s *Stats) field(name string) *int {
+	switch name {
+	case "hp":
+		return &s.HP
+	case "attack":
+		return &s.Attack
+	case "defense":
+		return &s.Defense
+	case "special-attack":
+		return &s.SpecialAttack
+	case "special-defense":
+		return &s.SpecialDefense
+	case "speed":
+		return &s.Speed
+	}
+	return nil
+}
+
+func (s Stats) Total() int {
+	return s.HP + s.Attack + s.Defense + s.SpecialAttack + s.SpecialDefense + s.Speed
+}
+
+// AddEffort adds the effort values a defeated Pokemon yields, keeping
+// every stat under MaxEV and the total under MaxTotalEV.
+func (s Stats) AddEffort(yield Stats) Stats {
+	for _, name := range StatNames {
+		room := min(MaxEV-s.Get(name), MaxTotalEV-s.Total())
+		s.Set(name, s.Get(name)+max(0, min(yield.Get(name), room)))
+	}
+	return s
+}
+
+// Nature raises one stat by 10% and lowers another by 10%. The five
+// natures that would raise and lower the same stat change nothing.
+type Nature struct {
+	Name      string
+	Increased string
+	Decreased string
+}
+
+// Natures are all 25 natures in their index order.
+var Natures = []Nature{
+	{"hardy", "attack", "attack"},
+	{"lonely", "attack", "defense"},
+	{"brave", "attack", "speed"},
+	{"adamant", "attack", "special-attack"},
+	{"naughty", "attack", "special-defense"},
+	{"bold", "defense", "attack"},
+	{"docile", "defense", "defense"},
+	{"relaxed", "defense", "speed"},
+	{"impish", "defense", "special-attack"},
+	{"lax", "defense", "special-defense"},
+	{"timid", "speed", "attack"},
+	{"hasty", "speed", "defense"},
+	{"serious", "speed", "speed"},
+	{"jolly", "speed", "special-attack"},
+	{"naive", "speed", "special-defense"},
+	{"modest", "special-attack", "attack"},
+	{"mild", "special-attack", "defense"},
+	{"quiet", "special-attack", "speed"},
+	{"bashful", "special-attack", "special-attack"},
+	{"rash", "special-attack", "special-defense"},
+	{"calm", "special-defense", "attack"},
+	{"gentle", "special-defense", "defense"},
+	{"sassy", "special-defense", "speed"},
+	{"careful", "special-defense", "special-attack"},
+	{"quirky", "special-defense", "special-defense"},
+}
+
+// NatureByName returns the nature called name.
+func NatureByName(name string) (Nature, bool) {
+	for _, nature := range Natures {
+		if nature.Name == name {
+			return nature, true
+		}
+	}
+	return Nature{}, false
+}
+
+// RandomNature picks one of the 25 natures.
+func RandomNature(rng Rand) Nature {
+	return Natures[rng.Intn(len(Natures))]
+}
+
+// RandomIVs rolls each individual value from 0 to MaxIV.
+func RandomIVs(rng Rand) Stats {
+	var ivs Stats
+	for _, name := range StatNames {
+		ivs.Set(name, rng.Intn(MaxIV+1))
+	}
+	return ivs
+}
+
+// CalcStats turns base stats into the stats of a Pokemon at level with the
+// mainline formula: individual values add up to 31 points and every 4
+// effort values one point at level 100, then the nature scales what isn't
+// HP.
+func CalcStats(base, ivs, evs Stats, level int, nature Nature) Stats {
+	var stats Stats
+	for _, name := range StatNames {
+		points := (2*base.Get(name) + ivs.Get(name) + evs.Get(name)/4) * level / 100
+		if name == "hp" {
+			stats.HP = points + level + 10
+			continue
+		}
+		value := points + 5
+		if nature.Increased != nature.Decreased {
+			switch name {
+			case nature.Increased:
+				value = value * 110 / 100
+			case nature.Decreased:
+				value = value * 90 / 100
+			}
+		}
+		stats.Set(name, value)
+	}
+	return stats
+}
@@ -0,0 +1,419 @@
+// Package lineedit is a small line editor for raw mode terminals, with
+// history, reverse search and tab completion.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// CompleteFunc returns the candidates for the word being typed. before is
+// the text left of the cursor, the word is whatever follows its last
+// space. Candidates are whole words, the editor filters them by prefix.
+type CompleteFunc func(before string) []string
+
+type Editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	History  *History
+	Complete CompleteFunc
+}
+
+// NewEditor reads keys from in and draws on out. in is expected to be a
+// terminal in raw mode, see MakeRaw.
+func NewEditor(in io.Reader, out io.Writer) *Editor {
+	return &Editor{
+		in:      bufio.NewReader(in),
+		out:     out,
+		History: NewHistory(0),
+	}
+}
+
+// key codes of the control characters the editor handles
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+
+	// escape sequences are mapped to these, past the range of runes
+	keyUp = utf8.MaxRune + 1 + iota
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// state of the line being edited
+type lineState struct {
+	prompt string
+	line   []rune
+	pos    int
+
+	historyIndex int    // History.Len() while not browsing
+	draft        string // the line typed before browsing history
+
+	lastKeyWasTab bool
+}
+
+// ReadLine shows prompt and returns the line entered, without the newline.
+// It returns io.EOF on Ctrl-D at an empty line and ErrInterrupted on
+// Ctrl-C. Accepted lines are added to the history.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	s := &lineState{prompt: prompt, historyIndex: e.History.Len()}
+	e.refresh(s)
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			if err == io.EOF && len(s.line) > 0 {
+				e.write("\n")
+				return string(s.line), nil
+			}
+			return "", err
+		}
+
+		wasTab := s.lastKeyWasTab
+		s.lastKeyWasTab = false
+
+		switch key {
+		case keyEnter, keyLF:
+			e.write("\n")
+			line := string(s.line)
+			e.History.Add(line)
+			return line, nil
+		case keyCtrlC:
+			e.write("^C\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(s.line) == 0 {
+				e.write("\n")
+				return "", io.EOF
+			}
+			e.deleteAt(s, s.pos)
+		case keyTab:
+			e.complete(s, wasTab)
+			s.lastKeyWasTab = true
+		case keyCtrlR:
+			line, accepted, err := e.reverseSearch(s)
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				e.write("\n")
+				e.History.Add(line)
+				return line, nil
+			}
+		case keyBackspace, keyCtrlH:
+			if s.pos > 0 {
+				s.pos--
+				e.deleteAt(s, s.pos)
+			}
+		case keyDelete:
+			e.deleteAt(s, s.pos)
+		case keyLeft, keyCtrlB:
+			if s.pos > 0 {
+				s.pos--
+			}
+		case keyRight, keyCtrlF:
+			if s.pos < len(s.line) {
+				s.pos++
+			}
+		case keyHome, keyCtrlA:
+			s.pos = 0
+		case keyEnd, keyCtrlE:
+			s.pos = len(s.line)
+		case keyUp, keyCtrlP:
+			e.browseHistory(s, -1)
+		case keyDown, keyCtrlN:
+			e.browseHistory(s, 1)
+		case keyCtrlK:
+			s.line = s.line[:s.pos]
+		case keyCtrlU:
+			s.line = append([]rune{}, s.line[s.pos:]...)
+			s.pos = 0
+		case keyCtrlW:
+			start := wordStart(s.line, s.pos)
+			s.line = append(s.line[:start], s.line[s.pos:]...)
+			s.pos = start
+		case keyCtrlL:
+			e.write("\x1b[H\x1b[2J")
+		case keyUnknown, keyEscape, keyCtrlG:
+			// ignored
+		default:
+			if key < ' ' {
+				break // other control characters
+			}
+			s.line = append(s.line[:s.pos], append([]rune{key}, s.line[s.pos:]...)...)
+			s.pos++
+		}
+		e.refresh(s)
+	}
+}
+
+func (e *Editor) write(text string) {
+	io.WriteString(e.out, text)
+}
+
+func (e *Editor) deleteAt(s *lineState, pos int) {
+	if pos < len(s.line) {
+		s.line = append(s.line[:pos], s.line[pos+1:]...)
+	}
+}
+
+// refresh redraws the whole line and puts the cursor back where it was.
+func (e *Editor) refresh(s *lineState) {
+	text := "\r" + s.prompt + string(s.line) + "\x1b[K"
+	if back := len(s.line) - s.pos; back > 0 {
+		text += fmt.Sprintf("\x1b[%dD", back)
+	}
+	e.write(text)
+}
+
+// readKey reads one key press, turning escape sequences into key codes.
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != keyEscape {
+		return r, nil
+	}
+
+	// a lone escape is followed by nothing, don't block waiting for more
+	if e.in.Buffered() == 0 {
+		return keyEscape, nil
+	}
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+	code, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+	if code < '0' || code > '9' {
+		return keyUnknown, nil
+	}
+	// sequences like ESC [ 3 ~, possibly with parameters: ESC [ 1 ; 5 ~
+	params := string(code)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r == '~' {
+			break
+		}
+		if (r < '0' || r > '9') && r != ';' {
+			return keyUnknown, nil
+		}
+		params += string(r)
+	}
+	switch strings.SplitN(params, ";", 2)[0] {
+	case "1", "7":
+		return keyHome, nil
+	case "4", "8":
+		return keyEnd, nil
+	case "3":
+		return keyDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+// browseHistory moves through the history, dir -1 being older. Going past
+// the newest entry brings back what was being typed.
+func (e *Editor) browseHistory(s *lineState, dir int) {
+	next := s.historyIndex + dir
+	if next < 0 || next > e.History.Len() {
+		return
+	}
+	if s.historyIndex == e.History.Len() {
+		s.draft = string(s.line)
+	}
+	s.historyIndex = next
+	if next == e.History.Len() {
+		s.line = []rune(s.draft)
+	} else {
+		s.line = []rune(e.History.At(next))
+	}
+	s.pos = len(s.line)
+}
+
+func wordStart(line []rune, pos int) int {
+	start := pos
+	for start > 0 && line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	return start
+}
+
+// complete extends the word left of the cursor. A unique match is
+// completed with a trailing space, several matches are completed up to
+// their common prefix, and a second tab lists them.
+func (e *Editor) complete(s *lineState, secondTab bool) {
+	if e.Complete == nil {
+		return
+	}
+	before := string(s.line[:s.pos])
+	start := strings.LastIndex(before, " ") + 1
+	prefix := before[start:]
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, candidate := range e.Complete(before) {
+		if strings.HasPrefix(candidate, prefix) && !seen[candidate] {
+			seen[candidate] = true
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	sort.Strings(matches)
+
+	insert := commonPrefix(matches)[len(prefix):]
+	if len(matches) == 1 {
+		insert += " "
+	}
+	if insert != "" {
+		e.insertText(s, insert)
+		return
+	}
+	if secondTab {
+		e.write("\n" + strings.Join(matches, "  ") + "\n")
+	}
+}
+
+func (e *Editor) insertText(s *lineState, text string) {
+	runes := []rune(text)
+	s.line = append(s.line[:s.pos], append(runes, s.line[s.pos:]...)...)
+	s.pos += len(runes)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// reverseSearch runs a Ctrl-R search. Enter accepts the match as the line
+// to run, any movement key puts the match on the line for editing and
+// Ctrl-C or Ctrl-G gives back the line as it was.
+func (e *Editor) reverseSearch(s *lineState) (string, bool, error) {
+	var query []rune
+	matchIndex := e.History.Len()
+	match := ""
+	failed := false
+
+	// find searches for query starting at from and going back in time
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < e.History.Len() && strings.Contains(e.History.At(i), string(query)) {
+				matchIndex, match, failed = i, e.History.At(i), false
+				return
+			}
+		}
+		failed = true
+	}
+
+	draw := func() {
+		label := "(reverse-i-search)"
+		if failed {
+			label = "(failed reverse-i-search)"
+		}
+		e.write("\r" + label + "`" + string(query) + "': " + match + "\x1b[K")
+	}
+	draw()
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", false, err
+		}
+		switch key {
+		case keyEnter, keyLF:
+			return match, true, nil
+		case keyCtrlC, keyCtrlG:
+			return "", false, nil
+		case keyCtrlR:
+			if len(query) > 0 {
+				find(matchIndex - 1)
+			}
+		case keyBackspace, keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				if len(query) == 0 {
+					matchIndex, match, failed = e.History.Len(), "", false
+				} else {
+					find(e.History.Len() - 1)
+				}
+			}
+		case keyLeft, keyRight, keyUp, keyDown, keyHome, keyEnd, keyEscape, keyCtrlA, keyCtrlE, keyTab:
+			if match != "" {
+				s.line = []rune(match)
+				s.pos = len(s.line)
+				s.historyIndex = e.History.Len()
+			}
+			return "", false, nil
+		default:
+			if key < ' ' || key > utf8.MaxRune {
+				break
+			}
+			query = append(query, key)
+			find(matchIndex) // the current match is kept if it still fits
+		}
+		draw()
+	}
+}
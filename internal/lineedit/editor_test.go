@@ -0,0 +1,135 @@
+package lineedit
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLine(t *testing.T, editor *Editor) string {
+	t.Helper()
+	line, err := editor.ReadLine("> ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return line
+}
+
+func TestEditing(t *testing.T) {
+	cases := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{name: "plain", keys: "map\r", expected: "map"},
+		{name: "backspace", keys: "mapx\x7f\r", expected: "map"},
+		{name: "left and insert", keys: "mp\x1b[Da\r", expected: "map"},
+		{name: "home and end", keys: "apb\x1b[H\x1b[1~m\x1b[F\x05\x7f\r", expected: "map"},
+		{name: "delete key", keys: "mapp\x1b[D\x1b[3~\r", expected: "map"},
+		{name: "ctrl-u", keys: "junk\x15map\r", expected: "map"},
+		{name: "ctrl-w", keys: "catch pikachu\x17bulbasaur\r", expected: "catch bulbasaur"},
+		{name: "ctrl-k", keys: "mapjunk\x01\x06\x06\x06\x0b\r", expected: "map"},
+		{name: "unicode", keys: "catch flabébé\x7f\x7fe\r", expected: "catch flabée"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			editor := NewEditor(strings.NewReader(c.keys), io.Discard)
+			if line := readLine(t, editor); line != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, line)
+			}
+		})
+	}
+}
+
+func TestCtrlCAndCtrlD(t *testing.T) {
+	editor := NewEditor(strings.NewReader("half\x03\x04"), io.Discard)
+	if _, err := editor.ReadLine("> "); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected ErrInterrupted, got %v", err)
+	}
+	if _, err := editor.ReadLine("> "); err != io.EOF {
+		t.Errorf("expected io.EOF on ctrl-d at an empty line, got %v", err)
+	}
+}
+
+func TestHistoryBrowsing(t *testing.T) {
+	keys := "map\r" + "explore canalave-city-area\r" +
+		"\x1b[A\x1b[A\r" + // two up: map
+		"draft\x1b[A\x1b[B\r" // up and back down restores the draft
+	editor := NewEditor(strings.NewReader(keys), io.Discard)
+
+	expected := []string{"map", "explore canalave-city-area", "map", "draft"}
+	for _, want := range expected {
+		if line := readLine(t, editor); line != want {
+			t.Errorf("expected %q, got %q", want, line)
+		}
+	}
+}
+
+func TestReverseSearch(t *testing.T) {
+	keys := "catch pikachu\r" + "map\r" + "catch bulbasaur\r" +
+		"\x12catch\r" + // newest match
+		"\x12catch\x12\r" + // ctrl-r again goes to the older one
+		"\x12map\x1b[C x\r" + // arrow keys put the match on the line for editing
+		"\x12zzz\r" // no match accepts an empty line
+	editor := NewEditor(strings.NewReader(keys), io.Discard)
+	for i := 0; i < 3; i++ {
+		readLine(t, editor)
+	}
+
+	expected := []string{"catch bulbasaur", "catch pikachu", "map x", ""}
+	for _, want := range expected {
+		if line := readLine(t, editor); line != want {
+			t.Errorf("expected %q, got %q", want, line)
+		}
+	}
+}
+
+func TestTabCompletion(t *testing.T) {
+	complete := func(before string) []string {
+		if !strings.Contains(before, " ") {
+			return []string{"catch", "cache", "map", "mapb"}
+		}
+		return []string{"pikachu", "pichu"}
+	}
+	cases := []struct {
+		keys     string
+		expected string
+	}{
+		{keys: "ma\t\r", expected: "map"},   // common prefix only, map and mapb both match
+		{keys: "catc\t\r", expected: "catch "},
+		{keys: "catch pik\t\r", expected: "catch pikachu "},
+		{keys: "catch pi\t\t\r", expected: "catch pi"}, // ambiguous, second tab only lists
+		{keys: "zz\t\r", expected: "zz"},
+	}
+	for _, c := range cases {
+		editor := NewEditor(strings.NewReader(c.keys), io.Discard)
+		editor.Complete = complete
+		if line := readLine(t, editor); line != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.keys, c.expected, line)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	history, err := LoadHistory(path, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, line := range []string{"map", "map", "  ", "mapb", "explore a", "explore b"} {
+		history.Add(line)
+	}
+	if history.Len() != 3 || history.At(0) != "mapb" {
+		t.Errorf("expected the newest 3 distinct lines, got %v", history.entries)
+	}
+
+	reloaded, err := LoadHistory(path, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reloaded.Len() != 3 || reloaded.At(0) != "mapb" || reloaded.At(2) != "explore b" {
+		t.Errorf("expected the history to survive a restart, got %v", reloaded.entries)
+	}
+}
@@ -0,0 +1,94 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// History keeps entered lines, oldest first. When it was loaded from a
+// file every new line is appended to that file as well.
+type History struct {
+	entries []string
+	max     int
+	path    string
+}
+
+func NewHistory(max int) *History {
+	return &History{max: max}
+}
+
+// LoadHistory reads the history file at path, which doesn't have to exist
+// yet. Only the newest max lines are kept, and the file is rewritten when
+// it had grown past that.
+func LoadHistory(path string, max int) (*History, error) {
+	history := &History{max: max, path: path}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(history.entries) > max {
+		history.entries = history.entries[len(history.entries)-max:]
+		data := strings.Join(history.entries, "\n") + "\n"
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// Add records line, skipping blank lines and repeats of the last one.
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.ContainsAny(line, "\r\n") {
+		return nil
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return nil
+	}
+	h.entries = append(h.entries, line)
+	if h.max > 0 && len(h.entries) > h.max {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// At returns the i-th entry, 0 being the oldest.
+func (h *History) At(i int) string {
+	return h.entries[i]
+}
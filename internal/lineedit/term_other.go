@@ -0,0 +1,15 @@
+//go:build !linux
+
+package lineedit
+
+import "errors"
+
+// IsTerminal always reports false outside linux, so callers fall back to
+// reading plain lines.
+func IsTerminal(fd uintptr) bool {
+	return false
+}
+
+func MakeRaw(fd uintptr) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
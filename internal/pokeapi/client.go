@@ -0,0 +1,165 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
+)
+
+const DefaultBaseURL = "https://pokeapi.co/api/v2/"
+
+// Client talks to the PokeAPI, answering from the cache whenever it can.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	cache      *pokecache.Cache
+	debug      io.Writer
+	timeout    time.Duration
+}
+
+// Options configures a Client created with NewClient.
+type Options struct {
+	// BaseURL defaults to DefaultBaseURL.
+	BaseURL string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Cache is optional, without it every call goes to the network.
+	Cache *pokecache.Cache
+	// Debug receives a line for every response saying where it came from.
+	// Nil means no diagnostics.
+	Debug io.Writer
+	// Timeout bounds every request, reading the body included. Zero means
+	// requests only end with their context.
+	Timeout time.Duration
+}
+
+// SetDebug changes where diagnostics go, nil turns them off.
+func (c *Client) SetDebug(debug io.Writer) {
+	if debug == nil {
+		debug = io.Discard
+	}
+	c.debug = debug
+}
+
+// SetTimeout changes how long a request may take, 0 means no limit.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Cache returns the cache the client answers from, nil when it has none.
+func (c *Client) Cache() *pokecache.Cache {
+	return c.cache
+}
+
+func NewClient(options Options) *Client {
+	baseURL := options.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	client := &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		cache:      options.Cache,
+		timeout:    options.Timeout,
+	}
+	client.SetDebug(options.Debug)
+	return client
+}
+
+// URL returns the full URL of an endpoint path such as "pokemon/pikachu/".
+func (c *Client) URL(path string) string {
+	return c.baseURL + strings.TrimPrefix(path, "/")
+}
+
+// getJSON fetches fullURL (or takes it from the cache) and decodes it into v.
+func (c *Client) getJSON(ctx context.Context, fullURL string, v any) error {
+	data, err := c.get(ctx, fullURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error parsing json-encoded data from %s: %w", fullURL, err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, fullURL string) ([]byte, error) {
+	if c.cache == nil {
+		fetched, err := c.fetch(ctx, fullURL, nil)
+		return fetched.Val, err
+	}
+	// concurrent misses for the same URL share a single request, and an
+	// expired entry is revalidated rather than downloaded again
+	source := "DATA FOUND IN THE CACHE"
+	data, err := c.cache.GetOrRevalidate(fullURL, func(stale *pokecache.Stale) (pokecache.Fetched, error) {
+		fetched, err := c.fetch(ctx, fullURL, stale)
+		source = "DATA FETCHED FROM INTERNET"
+		if fetched.NotModified {
+			source = "DATA REVALIDATED, UNCHANGED SINCE IT WAS CACHED"
+		}
+		return fetched, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(c.debug, source)
+	return data, nil
+}
+
+// fetch requests fullURL from the network, conditionally on the stale
+// copy's validators when there is one.
+func (c *Client) fetch(ctx context.Context, fullURL string, stale *pokecache.Stale) (pokecache.Fetched, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return pokecache.Fetched{}, fmt.Errorf("error creating request for %s: %w", fullURL, err)
+	}
+	if stale != nil {
+		if stale.Validators.ETag != "" {
+			req.Header.Set("If-None-Match", stale.Validators.ETag)
+		}
+		if stale.Validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stale.Validators.LastModified)
+		}
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return pokecache.Fetched{}, fmt.Errorf("error fetching %s: %w", fullURL, err)
+	}
+	defer res.Body.Close()
+
+	validators := pokecache.Validators{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	if res.StatusCode == http.StatusNotModified && stale != nil {
+		return pokecache.Fetched{Validators: validators, NotModified: true}, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// never cache these, the body is an error page and not the resource
+		return pokecache.Fetched{}, newAPIError(res, fullURL)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return pokecache.Fetched{}, fmt.Errorf("error reading response from %s: %w", fullURL, err)
+	}
+	return pokecache.Fetched{Val: data, Validators: validators}, nil
+}
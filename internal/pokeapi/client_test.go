@@ -0,0 +1,233 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
+)
+
+// newTestServer serves canned PokeAPI responses and counts the requests
+// that actually reach it.
+func newTestServer(t *testing.T, routes map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestListLocationAreas(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{
+		"/api/v2/location-area?offset=0&limit=2": `{
+			"count": 3,
+			"next": "https://pokeapi.co/api/v2/location-area?offset=2&limit=2",
+			"previous": null,
+			"results": [{"name": "canalave-city-area"}, {"name": "eterna-city-area"}]
+		}`,
+	})
+	client := NewClient(Options{BaseURL: server.URL + "/api/v2"})
+
+	page, err := client.ListLocationAreas(context.Background(), client.LocationAreaPageURL(0, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Results) != 2 || page.Results[1].Name != "eterna-city-area" {
+		t.Errorf("unexpected results: %+v", page.Results)
+	}
+	if page.Next == nil || page.Previous != nil {
+		t.Errorf("unexpected pagination: next=%v previous=%v", page.Next, page.Previous)
+	}
+}
+
+func TestGetLocationArea(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{
+		"/api/v2/location-area/canalave-city-area/": `{
+			"name": "canalave-city-area",
+			"pokemon_encounters": [
+				{"pokemon": {"name": "tentacool"}},
+				{"pokemon": {"name": "staryu"}}
+			]
+		}`,
+	})
+	client := NewClient(Options{BaseURL: server.URL + "/api/v2/"})
+
+	area, err := client.GetLocationArea(context.Background(), "canalave-city-area")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(area.PokemonEncounters) != 2 || area.PokemonEncounters[0].Pokemon.Name != "tentacool" {
+		t.Errorf("unexpected encounters: %+v", area.PokemonEncounters)
+	}
+}
+
+func TestGetPokemonUsesCache(t *testing.T) {
+	server, hits := newTestServer(t, map[string]string{
+		"/api/v2/pokemon/pikachu/": `{"name": "pikachu", "base_experience": 112, "height": 4, "weight": 60}`,
+	})
+	client := NewClient(Options{
+		BaseURL: server.URL + "/api/v2/",
+		Cache:   pokecache.NewCache(time.Minute),
+	})
+
+	for i := 0; i < 3; i++ {
+		pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pokemon.Name != "pikachu" || pokemon.BaseExperience != 112 {
+			t.Errorf("unexpected pokemon: %s %d", pokemon.Name, pokemon.BaseExperience)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request to reach the server, got %d", hits.Load())
+	}
+}
+
+func TestConcurrentRequestsShareAFetch(t *testing.T) {
+	server, hits := newTestServer(t, map[string]string{
+		"/api/v2/pokemon/pikachu/": `{"name": "pikachu", "base_experience": 112, "height": 4, "weight": 60}`,
+	})
+	client := NewClient(Options{
+		BaseURL: server.URL + "/api/v2/",
+		Cache:   pokecache.NewCache(time.Minute),
+	})
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if pokemon, err := client.GetPokemon(context.Background(), "pikachu"); err != nil || pokemon.Name != "pikachu" {
+				t.Errorf("expected pikachu, got %+v (%v)", pokemon, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if hits.Load() != 1 {
+		t.Errorf("expected 1 request to reach the server, got %d", hits.Load())
+	}
+}
+
+// versionedServer serves a pokemon that changes with its version, with an
+// ETag or a Last-Modified date, and answers conditional requests for the
+// current version with a 304. It counts both kinds of responses.
+type versionedServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	version     int
+	full        atomic.Int32
+	notModified atomic.Int32
+}
+
+func newVersionedServer(t *testing.T, useETag bool) *versionedServer {
+	t.Helper()
+	s := &versionedServer{version: 1}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		version := s.version
+		s.mu.Unlock()
+		etag := fmt.Sprintf(`"v%d"`, version)
+		lastModified := time.Date(2025, 1, version, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+		var current bool
+		if useETag {
+			w.Header().Set("ETag", etag)
+			current = r.Header.Get("If-None-Match") == etag
+		} else {
+			w.Header().Set("Last-Modified", lastModified)
+			current = r.Header.Get("If-Modified-Since") == lastModified
+		}
+		if current {
+			s.notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.full.Add(1)
+		fmt.Fprintf(w, `{"name": "pikachu", "base_experience": %d}`, 100+version)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRevalidation(t *testing.T) {
+	for name, useETag := range map[string]bool{"etag": true, "last-modified": false} {
+		t.Run(name, func(t *testing.T) {
+			server := newVersionedServer(t, useETag)
+			cache, _ := pokecache.NewCacheWithOptions(pokecache.Options{Duration: 50 * time.Millisecond, MaxStale: time.Minute})
+			defer cache.Close()
+			client := NewClient(Options{BaseURL: server.URL, Cache: cache})
+			expect := func(baseExperience int, full, notModified int32) {
+				t.Helper()
+				pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+				if err != nil || pokemon.BaseExperience != baseExperience {
+					t.Fatalf("expected base experience %d, got %+v (%v)", baseExperience, pokemon, err)
+				}
+				if server.full.Load() != full || server.notModified.Load() != notModified {
+					t.Errorf("expected %d full and %d not modified responses, got %d and %d",
+						full, notModified, server.full.Load(), server.notModified.Load())
+				}
+			}
+
+			expect(101, 1, 0)
+			expect(101, 1, 0) // fresh, straight from the cache
+			time.Sleep(70 * time.Millisecond)
+			expect(101, 1, 1) // expired but unchanged: a 304
+			expect(101, 1, 1) // and fresh again
+
+			server.mu.Lock()
+			server.version = 2
+			server.mu.Unlock()
+			time.Sleep(70 * time.Millisecond)
+			expect(102, 2, 1) // changed: the whole body
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+	client := NewClient(Options{BaseURL: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetPokemon(ctx, "pikachu"); err == nil {
+		t.Errorf("expected an error from a cancelled context")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // stall until the client gives up
+	}))
+	t.Cleanup(server.Close)
+	cache := pokecache.NewCache(time.Minute)
+	client := NewClient(Options{BaseURL: server.URL, Cache: cache, Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the request to stop after the timeout, it took %v", elapsed)
+	}
+	if _, ok := cache.Get(client.URL("pokemon/pikachu/")); ok {
+		t.Errorf("expected nothing to be cached from a timed out request")
+	}
+}
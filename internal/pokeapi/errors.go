@@ -0,0 +1,48 @@
+package pokeapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kinds of API errors, use errors.Is to check which one an error is.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrRateLimited = errors.New("rate limited")
+	ErrServerError = errors.New("server error")
+)
+
+// APIError is returned for every response with a non-2xx status code.
+type APIError struct {
+	StatusCode int
+	URL        string
+	// RetryAfter is the raw Retry-After header, if the server sent one.
+	RetryAfter string
+}
+
+func newAPIError(res *http.Response, url string) *APIError {
+	return &APIError{
+		StatusCode: res.StatusCode,
+		URL:        url,
+		RetryAfter: res.Header.Get("Retry-After"),
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Unwrap lets errors.Is match the error against ErrNotFound, ErrRateLimited
+// and ErrServerError.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServerError
+	}
+	return nil
+}
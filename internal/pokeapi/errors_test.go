@@ -0,0 +1,73 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
+)
+
+func TestStatusErrors(t *testing.T) {
+	cases := []struct {
+		status   int
+		expected error
+	}{
+		{status: http.StatusNotFound, expected: ErrNotFound},
+		{status: http.StatusTooManyRequests, expected: ErrRateLimited},
+		{status: http.StatusInternalServerError, expected: ErrServerError},
+		{status: http.StatusBadGateway, expected: ErrServerError},
+	}
+	for _, c := range cases {
+		t.Run(http.StatusText(c.status), func(t *testing.T) {
+			var hits atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.Header().Set("Retry-After", "3")
+				http.Error(w, http.StatusText(c.status), c.status)
+			}))
+			defer server.Close()
+			cache := pokecache.NewCache(time.Minute)
+			client := NewClient(Options{BaseURL: server.URL, Cache: cache})
+
+			for i := 0; i < 2; i++ {
+				_, err := client.GetPokemon(context.Background(), "pikachuu")
+				if !errors.Is(err, c.expected) {
+					t.Fatalf("expected %v, got %v", c.expected, err)
+				}
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != c.status || apiErr.RetryAfter != "3" {
+					t.Errorf("expected an APIError with status %d, got %#v", c.status, apiErr)
+				}
+			}
+			// both calls must reach the server, the error page is never cached
+			if hits.Load() != 2 {
+				t.Errorf("expected 2 requests, got %d", hits.Load())
+			}
+			if _, ok := cache.Get(client.URL("pokemon/pikachuu/")); ok {
+				t.Errorf("expected error response not to be cached")
+			}
+		})
+	}
+}
+
+func TestOtherStatusIsStillAnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	client := NewClient(Options{BaseURL: server.URL})
+
+	_, err := client.GetPokemon(context.Background(), "pikachu")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServerError) {
+		t.Errorf("403 should not match any of the error kinds")
+	}
+}
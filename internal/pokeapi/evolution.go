@@ -0,0 +1,62 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// EvolutionChain is the family tree of a species, from its earliest form
+// in Chain.
+type EvolutionChain struct {
+	ID    int       `json:"id"`
+	Chain ChainLink `json:"chain"`
+}
+
+// ChainLink is one species in a chain. EvolutionDetails are the ways to
+// evolve into it from the link above, any one of them is enough.
+type ChainLink struct {
+	IsBaby           bool              `json:"is_baby"`
+	Species          NamedResource     `json:"species"`
+	EvolutionDetails []EvolutionDetail `json:"evolution_details"`
+	EvolvesTo        []ChainLink       `json:"evolves_to"`
+}
+
+// EvolutionDetail is one way to evolve: the trigger and every condition
+// that has to hold when it fires. Unset conditions are nil or empty.
+type EvolutionDetail struct {
+	Trigger               NamedResource  `json:"trigger"`
+	Item                  *NamedResource `json:"item"`
+	HeldItem              *NamedResource `json:"held_item"`
+	KnownMove             *NamedResource `json:"known_move"`
+	KnownMoveType         *NamedResource `json:"known_move_type"`
+	Location              *NamedResource `json:"location"`
+	PartySpecies          *NamedResource `json:"party_species"`
+	PartyType             *NamedResource `json:"party_type"`
+	TradeSpecies          *NamedResource `json:"trade_species"`
+	Gender                *int           `json:"gender"`
+	MinLevel              *int           `json:"min_level"`
+	MinHappiness          *int           `json:"min_happiness"`
+	MinBeauty             *int           `json:"min_beauty"`
+	MinAffection          *int           `json:"min_affection"`
+	RelativePhysicalStats *int           `json:"relative_physical_stats"`
+	NeedsOverworldRain    bool           `json:"needs_overworld_rain"`
+	TimeOfDay             string         `json:"time_of_day"`
+	TurnUpsideDown        bool           `json:"turn_upside_down"`
+}
+
+// GetEvolutionChain takes PokemonSpecies.EvolutionChain.URL. Only the ID
+// at its end is used, so the chain comes from the client's own base URL.
+func (c *Client) GetEvolutionChain(ctx context.Context, chainURL string) (EvolutionChain, error) {
+	id, err := strconv.Atoi(path.Base(strings.TrimSuffix(chainURL, "/")))
+	if err != nil {
+		return EvolutionChain{}, fmt.Errorf("error fetching evolution chain %q: not a chain url", chainURL)
+	}
+	var chain EvolutionChain
+	if err := c.getJSON(ctx, c.URL("evolution-chain/"+strconv.Itoa(id)+"/"), &chain); err != nil {
+		return EvolutionChain{}, fmt.Errorf("error fetching evolution chain %d: %w", id, err)
+	}
+	return chain, nil
+}
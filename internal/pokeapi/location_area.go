@@ -1,4 +1,20 @@
-package main
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+type LocationAreaMap struct {
+	Count int `json:"count"`
+	Next *string `json:"next"`
+	Previous *string `json:"previous"`
+	Results []struct{ 
+		Name string `json:"name"`
+		URL string `json:"url"`
+	} `json:"results"`
+}
 
 type LocationArea struct {
 	ID                   int    `json:"id"`
@@ -51,4 +67,28 @@ type LocationArea struct {
 			} `json:"encounter_details"`
 		} `json:"version_details"`
 	} `json:"pokemon_encounters"`
-}
\ No newline at end of file
+}
+
+// LocationAreaPageURL returns the URL of a page of the location-area list.
+func (c *Client) LocationAreaPageURL(offset, limit int) string {
+	return c.URL(fmt.Sprintf("location-area?offset=%d&limit=%d", offset, limit))
+}
+
+// ListLocationAreas fetches one page of location areas. pageURL is either
+// built with LocationAreaPageURL or taken from a previous page's Next or
+// Previous.
+func (c *Client) ListLocationAreas(ctx context.Context, pageURL string) (LocationAreaMap, error) {
+	var locationAreaMap LocationAreaMap
+	if err := c.getJSON(ctx, pageURL, &locationAreaMap); err != nil {
+		return LocationAreaMap{}, fmt.Errorf("error fetching location areas map: %w", err)
+	}
+	return locationAreaMap, nil
+}
+
+func (c *Client) GetLocationArea(ctx context.Context, name string) (LocationArea, error) {
+	var locationArea LocationArea
+	if err := c.getJSON(ctx, c.URL("location-area/"+url.PathEscape(name)+"/"), &locationArea); err != nil {
+		return LocationArea{}, fmt.Errorf("error fetching location area %q: %w", name, err)
+	}
+	return locationArea, nil
+}
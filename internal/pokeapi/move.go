@@ -0,0 +1,38 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Move holds the move fields battles use. Power, Accuracy and PP are nil
+// for moves that don't have one, such as status moves or moves whose
+// power is worked out in battle.
+type Move struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Accuracy *int   `json:"accuracy"`
+	Power    *int   `json:"power"`
+	PP       *int   `json:"pp"`
+	Priority int    `json:"priority"`
+	Type     struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"type"`
+	DamageClass struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"damage_class"`
+	Meta *struct {
+		CritRate int `json:"crit_rate"`
+	} `json:"meta"`
+}
+
+func (c *Client) GetMove(ctx context.Context, name string) (Move, error) {
+	var move Move
+	if err := c.getJSON(ctx, c.URL("move/"+url.PathEscape(name)+"/"), &move); err != nil {
+		return Move{}, fmt.Errorf("error fetching move %q: %w", name, err)
+	}
+	return move, nil
+}
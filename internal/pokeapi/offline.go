@@ -0,0 +1,67 @@
+package pokeapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotAvailableOffline is returned for every request an OfflineTransport
+// has no recorded response for.
+var ErrNotAvailableOffline = errors.New("not available offline")
+
+// OfflineTransport answers requests from a bundle directory of recorded
+// responses instead of the network, see BundlePath for the layout.
+type OfflineTransport struct {
+	Dir string
+}
+
+func (t *OfflineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrNotAvailableOffline)
+	}
+	data, err := os.ReadFile(BundlePath(t.Dir, req.URL))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", req.URL, ErrNotAvailableOffline)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// BundlePath is where the response for u lives in a bundle directory. The
+// API path becomes the file path, so .../api/v2/pokemon/pikachu/ is
+// pokemon/pikachu.json, and a query string becomes a file inside the
+// resource's directory: location-area?offset=0&limit=20 is
+// location-area/limit=20&offset=0.json (parameters sorted). The host is
+// ignored, so the absolute next/previous URLs in a page work too.
+func BundlePath(dir string, u *url.URL) string {
+	resource := u.Path
+	if i := strings.Index(resource, "/api/v2/"); i >= 0 {
+		resource = resource[i+len("/api/v2/"):]
+	}
+	// cleaning it as an absolute path keeps ".." from leaving the bundle
+	resource = strings.Trim(path.Clean("/"+resource), "/")
+	if u.RawQuery != "" {
+		resource += "/" + u.Query().Encode()
+	}
+	return filepath.Join(dir, filepath.FromSlash(resource)+".json")
+}
@@ -0,0 +1,79 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundlePath(t *testing.T) {
+	cases := map[string]string{
+		"https://pokeapi.co/api/v2/pokemon/pikachu/":                 "pokemon/pikachu.json",
+		"http://127.0.0.1:8080/api/v2/pokemon/pikachu":               "pokemon/pikachu.json",
+		"https://pokeapi.co/api/v2/location-area?offset=20&limit=20": "location-area/limit=20&offset=20.json",
+		"https://pokeapi.co/api/v2/pokemon/..%2F..%2F..%2Fetc/":      "etc.json",
+	}
+	for raw, expected := range cases {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := BundlePath("bundle", u); got != filepath.Join("bundle", expected) {
+			t.Errorf("%s: expected %s, got %s", raw, filepath.Join("bundle", expected), got)
+		}
+	}
+}
+
+func TestOfflineTransport(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "pokemon"), 0o755)
+	os.WriteFile(filepath.Join(dir, "pokemon", "pikachu.json"), []byte(`{"name": "pikachu", "weight": 60}`), 0o644)
+	client := NewClient(Options{
+		HTTPClient: &http.Client{Transport: &OfflineTransport{Dir: dir}},
+	})
+
+	pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.Weight != 60 {
+		t.Errorf("expected the bundled pikachu, got %+v", pokemon.Name)
+	}
+
+	_, err = client.GetPokemon(context.Background(), "mew")
+	if !errors.Is(err, ErrNotAvailableOffline) {
+		t.Errorf("expected ErrNotAvailableOffline, got %v", err)
+	}
+}
+
+func TestRepositoryBundleDecodes(t *testing.T) {
+	client := NewClient(Options{
+		HTTPClient: &http.Client{Transport: &OfflineTransport{Dir: filepath.Join("..", "..", "testdata", "bundle")}},
+	})
+	ctx := context.Background()
+
+	page, err := client.ListLocationAreas(ctx, client.LocationAreaPageURL(0, 20))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Results) != 20 {
+		t.Errorf("expected a full first page, got %d results", len(page.Results))
+	}
+	area, err := client.GetLocationArea(ctx, "eterna-forest-area")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, encounter := range area.PokemonEncounters {
+		pokemon, err := client.GetPokemon(ctx, encounter.Pokemon.Name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.GetPokemonSpecies(ctx, pokemon.Species.Name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package pokeapi
+
+import "regexp"
+
+// ResourcePattern returns a pattern for pokecache.TTLPolicy matching the
+// URLs of every resource of one type: "pokemon" matches pokemon/pikachu/
+// but neither the pokemon list nor pokemon-species/pikachu/.
+func ResourcePattern(resource string) string {
+	return "/" + regexp.QuoteMeta(resource) + `/[^/?]+/?$`
+}
+
+// ListPattern returns a pattern for pokecache.TTLPolicy matching the pages
+// of one resource type's list, such as location-area?offset=0&limit=20.
+func ListPattern(resource string) string {
+	return "/" + regexp.QuoteMeta(resource) + `/?(\?.*)?$`
+}
@@ -0,0 +1,28 @@
+package pokeapi
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	client := NewClient(Options{})
+	cases := []struct {
+		pattern string
+		url     string
+		matches bool
+	}{
+		{ResourcePattern("pokemon"), client.URL("pokemon/pikachu/"), true},
+		{ResourcePattern("pokemon"), client.URL("pokemon-species/pikachu/"), false},
+		{ResourcePattern("pokemon"), client.URL("pokemon?offset=0&limit=20"), false},
+		{ResourcePattern("evolution-chain"), client.URL("evolution-chain/10/"), true},
+		{ListPattern("location-area"), client.LocationAreaPageURL(0, 20), true},
+		{ListPattern("location-area"), client.URL("location-area/?offset=20&limit=20"), true},
+		{ListPattern("location-area"), client.URL("location-area/canalave-city-area/"), false},
+	}
+	for _, c := range cases {
+		if got := regexp.MustCompile(c.pattern).MatchString(c.url); got != c.matches {
+			t.Errorf("%s against %s: expected %v, got %v", c.pattern, c.url, c.matches, got)
+		}
+	}
+}
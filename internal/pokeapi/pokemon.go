@@ -1,4 +1,10 @@
-package main
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
 
 type Pokemon struct {
 	ID             int    `json:"id"`
@@ -284,10 +290,10 @@ type Pokemon struct {
 	} `json:"past_abilities"`
 }
 
-type Pokedex struct {
-	Items map[string]Pokemon
+func (c *Client) GetPokemon(ctx context.Context, name string) (Pokemon, error) {
+	var pokemon Pokemon
+	if err := c.getJSON(ctx, c.URL("pokemon/"+url.PathEscape(name)+"/"), &pokemon); err != nil {
+		return Pokemon{}, fmt.Errorf("error fetching pokemon %q: %w", name, err)
+	}
+	return pokemon, nil
 }
-
-func (p *Pokedex) Add(pokemon Pokemon) {
-	p.Items[pokemon.Name] = pokemon
-}
\ No newline at end of file
@@ -0,0 +1,168 @@
+package pokeapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotRecorded is returned by a ReplayTransport for requests that are
+// not in its log.
+var ErrNotRecorded = errors.New("not in the recorded session")
+
+// Exchange is one request and its response, a line of a session log.
+type Exchange struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// Error is set instead of a response when the request itself failed.
+	Error string `json:"error,omitempty"`
+}
+
+// RecordingTransport passes requests on to Next and logs every exchange to
+// a JSONL session log.
+type RecordingTransport struct {
+	Next http.RoundTripper
+
+	mutex sync.Mutex
+	log   io.Writer
+}
+
+// NewRecordingTransport records to log, next defaults to
+// http.DefaultTransport.
+func NewRecordingTransport(next http.RoundTripper, log io.Writer) *RecordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{Next: next, log: log}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := Exchange{
+		Time:   time.Now().UTC(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+	res, err := t.Next.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		t.write(exchange)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		exchange.Error = err.Error()
+		t.write(exchange)
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body)) // the caller still gets to read it
+
+	exchange.Status = res.StatusCode
+	exchange.Header = res.Header
+	exchange.Body = string(body)
+	if err := t.write(exchange); err != nil {
+		return nil, fmt.Errorf("error recording %s: %w", req.URL, err)
+	}
+	return res, nil
+}
+
+func (t *RecordingTransport) write(exchange Exchange) error {
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, err = t.log.Write(append(line, '\n'))
+	return err
+}
+
+// ReplayTransport serves a session exclusively from a recorded log. Each
+// request gets the recorded exchanges for its method and URL in order,
+// and the last one again once they run out.
+type ReplayTransport struct {
+	mutex     sync.Mutex
+	exchanges map[string][]Exchange
+	served    map[string]int
+}
+
+func NewReplayTransport(log io.Reader) (*ReplayTransport, error) {
+	t := &ReplayTransport{
+		exchanges: make(map[string][]Exchange),
+		served:    make(map[string]int),
+	}
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // a pokemon response is one long line
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("session log line %d: %w", lineNumber, err)
+		}
+		key := exchange.Method + " " + exchange.URL
+		t.exchanges[key] = append(t.exchanges[key], exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func LoadReplayTransport(path string) (*ReplayTransport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return NewReplayTransport(file)
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + req.URL.String()
+
+	t.mutex.Lock()
+	exchanges := t.exchanges[key]
+	if len(exchanges) == 0 {
+		t.mutex.Unlock()
+		return nil, fmt.Errorf("%s: %w", key, ErrNotRecorded)
+	}
+	index := min(t.served[key], len(exchanges)-1)
+	t.served[key]++
+	exchange := exchanges[index]
+	t.mutex.Unlock()
+
+	if exchange.Error != "" {
+		return nil, fmt.Errorf("recorded error: %s", exchange.Error)
+	}
+	header := exchange.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(exchange.Body))),
+		ContentLength: int64(len(exchange.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package pokeapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	server, hits := newTestServer(t, map[string]string{
+		"/api/v2/pokemon/pikachu/": `{"id": 25, "name": "pikachu", "base_experience": 112}`,
+	})
+	var log bytes.Buffer
+	recorder := NewClient(Options{
+		BaseURL:    server.URL + "/api/v2",
+		HTTPClient: &http.Client{Transport: NewRecordingTransport(nil, &log)},
+	})
+	ctx := context.Background()
+	if _, err := recorder.GetPokemon(ctx, "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := recorder.GetPokemon(ctx, "missingno"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound while recording, got %v", err)
+	}
+	if lines := strings.Count(log.String(), "\n"); lines != 2 {
+		t.Fatalf("expected 2 recorded exchanges, got %d:\n%s", lines, log.String())
+	}
+
+	replay, err := NewReplayTransport(&log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close() // from here on everything has to come from the log
+	replayer := NewClient(Options{
+		BaseURL:    server.URL + "/api/v2",
+		HTTPClient: &http.Client{Transport: replay},
+	})
+	pokemon, err := replayer.GetPokemon(ctx, "pikachu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pokemon.ID != 25 || pokemon.BaseExperience != 112 {
+		t.Errorf("unexpected pokemon: %+v", pokemon)
+	}
+	var apiErr *APIError
+	_, err = replayer.GetPokemon(ctx, "missingno")
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the recorded 404 as an APIError, got %v", err)
+	}
+	if _, err := replayer.GetPokemon(ctx, "bulbasaur"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", hits.Load())
+	}
+}
+
+func TestReplayServesExchangesInOrder(t *testing.T) {
+	log := strings.NewReader(`
+{"method": "GET", "url": "http://pokeapi.invalid/a", "status": 503}
+{"method": "GET", "url": "http://pokeapi.invalid/a", "status": 200, "body": "ok"}
+`)
+	replay, err := NewReplayTransport(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var statuses []int
+	for range 3 {
+		req, _ := http.NewRequest(http.MethodGet, "http://pokeapi.invalid/a", nil)
+		res, err := replay.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res.Body.Close()
+		statuses = append(statuses, res.StatusCode)
+	}
+	if statuses[0] != 503 || statuses[1] != 200 || statuses[2] != 200 {
+		t.Errorf("expected 503 then 200 repeated, got %v", statuses)
+	}
+}
+
+func TestReplayRecordedError(t *testing.T) {
+	replay, err := NewReplayTransport(strings.NewReader(
+		`{"method": "GET", "url": "http://pokeapi.invalid/a", "error": "connection refused"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://pokeapi.invalid/a", nil)
+	if _, err := replay.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected the recorded error, got %v", err)
+	}
+}
+
+func TestReplayRejectsBadLog(t *testing.T) {
+	_, err := NewReplayTransport(strings.NewReader("{\"method\": \"GET\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+}
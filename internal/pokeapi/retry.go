@@ -0,0 +1,176 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryOptions configures a RetryTransport created with NewRetryTransport.
+type RetryOptions struct {
+	// MaxRetries is how many times a failed request is tried again, 0
+	// means it's tried once.
+	MaxRetries int
+	// BaseDelay is the wait before the first retry, doubled for every
+	// retry after it and capped at MaxDelay. It defaults to 200ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts, a Retry-After longer than
+	// that is not waited out. It defaults to 10s.
+	MaxDelay time.Duration
+	// Limiter throttles every attempt, retries included. Nil means no
+	// limit.
+	Limiter *Limiter
+}
+
+// RetryTransport retries GET and HEAD requests that fail with a network
+// error, a 429 or a 5xx, waiting with jittered exponential backoff or as
+// long as the server's Retry-After asks.
+type RetryTransport struct {
+	next    http.RoundTripper
+	options RetryOptions
+}
+
+func NewRetryTransport(next http.RoundTripper, options RetryOptions) *RetryTransport {
+	if options.BaseDelay <= 0 {
+		options.BaseDelay = 200 * time.Millisecond
+	}
+	if options.MaxDelay <= 0 {
+		options.MaxDelay = 10 * time.Second
+	}
+	return &RetryTransport{next: next, options: options}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		if t.options.Limiter != nil {
+			if err := t.options.Limiter.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		res, err := t.next.RoundTrip(req)
+		if !idempotent || attempt >= t.options.MaxRetries || !retryable(req, res, err) {
+			return res, err
+		}
+
+		delay := t.backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				if after > t.options.MaxDelay {
+					return res, nil // asked to wait longer than we're willing to
+				}
+				delay = after
+			}
+			// the connection can only be reused once the body is read
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+			res.Body.Close()
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether the outcome of an attempt is worth another
+// one. A cancelled or timed out request is not, it would fail the same way.
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// backoff is the wait before retry attempt+1: BaseDelay doubled attempt
+// times, capped at MaxDelay, then jittered to between half and all of it
+// so clients that failed together don't retry together.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.options.MaxDelay
+	if attempt < 32 {
+		delay = min(t.options.BaseDelay<<attempt, t.options.MaxDelay)
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, whichever is first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Limiter is a token bucket: it holds up to burst tokens, refills perSecond
+// of them every second, and every request takes one.
+type Limiter struct {
+	mutex     sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// NewLimiter returns a full Limiter, with a burst of at least 1. A
+// perSecond that isn't positive means no limit, and gives a nil Limiter:
+// a bucket that never refills would hold up every request after the first
+// burst for good.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if !(perSecond > 0) { // NaN included
+		return nil
+	}
+	burst = max(burst, 1)
+	return &Limiter{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// Wait takes a token, waiting for one to be refilled if the bucket is
+// empty. It gives up when ctx is done. A nil Limiter never waits.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mutex.Lock()
+		now := time.Now()
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.perSecond)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mutex.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.perSecond * float64(time.Second))
+		l.mutex.Unlock()
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyServer answers the first failures requests with fail and every
+// one after that with a pokemon, counting the requests that reach it.
+func newFlakyServer(t *testing.T, failures int32, fail http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			fail(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func newRetryingClient(server *httptest.Server, options RetryOptions) *Client {
+	return NewClient(Options{
+		BaseURL:    server.URL,
+		HTTPClient: &http.Client{Transport: NewRetryTransport(http.DefaultTransport, options)},
+	})
+}
+
+func status(code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	cases := map[string]http.HandlerFunc{
+		"server error": status(http.StatusServiceUnavailable),
+		"rate limited": status(http.StatusTooManyRequests),
+		"network error": func(w http.ResponseWriter, r *http.Request) {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close() // hang up without an answer
+		},
+	}
+	for name, fail := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, hits := newFlakyServer(t, 2, fail)
+			client := newRetryingClient(server, RetryOptions{MaxRetries: 3, BaseDelay: time.Millisecond})
+			pokemon, err := client.GetPokemon(context.Background(), "pikachu")
+			if err != nil || pokemon.Name != "pikachu" {
+				t.Fatalf("expected pikachu after two failures, got %+v (%v)", pokemon, err)
+			}
+			if hits.Load() != 3 {
+				t.Errorf("expected 3 attempts, got %d", hits.Load())
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, hits := newFlakyServer(t, 100, status(http.StatusInternalServerError))
+	client := newRetryingClient(server, RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond})
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); !errors.Is(err, ErrServerError) {
+		t.Errorf("expected ErrServerError, got %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected 1 attempt and 2 retries, got %d", hits.Load())
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	server, hits := newFlakyServer(t, 100, status(http.StatusNotFound))
+	client := newRetryingClient(server, RetryOptions{MaxRetries: 3, BaseDelay: time.Millisecond})
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d attempts", hits.Load())
+	}
+
+	// a cancelled request would fail the same way every time
+	stalled, stalledHits := newFlakyServer(t, 100, status(http.StatusServiceUnavailable))
+	client = newRetryingClient(stalled, RetryOptions{MaxRetries: 3, BaseDelay: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetPokemon(ctx, "pikachu"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the backoff to end with the context, got %v", err)
+	}
+	if stalledHits.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", stalledHits.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, hits := newFlakyServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client := newRetryingClient(server, RetryOptions{MaxRetries: 3, BaseDelay: time.Millisecond})
+	start := time.Now()
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait the second Retry-After asked for, waited %v", elapsed)
+	}
+
+	// longer than MaxDelay is given up on straight away
+	slow, slowHits := newFlakyServer(t, 100, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client = newRetryingClient(slow, RetryOptions{MaxRetries: 3, MaxDelay: time.Second})
+	if _, err := client.GetPokemon(context.Background(), "pikachu"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if hits.Load() != 2 || slowHits.Load() != 1 {
+		t.Errorf("expected 2 and 1 attempts, got %d and %d", hits.Load(), slowHits.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"3", 3 * time.Second, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := retryAfter(c.value, now)
+		if got != c.expected || ok != c.ok {
+			t.Errorf("%q: expected %v %v, got %v %v", c.value, c.expected, c.ok, got, ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	transport := NewRetryTransport(nil, RetryOptions{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	for attempt, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		expected *= time.Millisecond
+		for range 20 {
+			if got := transport.backoff(attempt); got < expected/2 || got > expected {
+				t.Errorf("attempt %d: expected %v to %v, got %v", attempt, expected/2, expected, got)
+			}
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(50, 2)
+	start := time.Now()
+	for range 6 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// a burst of 2, then 4 more at 50 a second
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("expected about 80ms for 6 requests, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	empty := NewLimiter(0.001, 1)
+	empty.Wait(ctx)
+	if err := empty.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected waiting on an empty bucket to end with the context, got %v", err)
+	}
+
+	for _, perSecond := range []float64{0, -1, math.NaN()} {
+		unlimited := NewLimiter(perSecond, 1)
+		if unlimited != nil {
+			t.Errorf("%v a second: expected no limit, got %+v", perSecond, unlimited)
+		}
+		for range 3 {
+			if err := unlimited.Wait(context.Background()); err != nil {
+				t.Errorf("%v a second: unexpected error: %v", perSecond, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// NamedResource is how PokeAPI refers to another resource.
+type NamedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Type is a Pokemon or move type with its damage relations, the rows and
+// columns of the type chart.
+type Type struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	DamageRelations struct {
+		DoubleDamageTo   []NamedResource `json:"double_damage_to"`
+		HalfDamageTo     []NamedResource `json:"half_damage_to"`
+		NoDamageTo       []NamedResource `json:"no_damage_to"`
+		DoubleDamageFrom []NamedResource `json:"double_damage_from"`
+		HalfDamageFrom   []NamedResource `json:"half_damage_from"`
+		NoDamageFrom     []NamedResource `json:"no_damage_from"`
+	} `json:"damage_relations"`
+}
+
+func (c *Client) GetType(ctx context.Context, name string) (Type, error) {
+	var pokemonType Type
+	if err := c.getJSON(ctx, c.URL("type/"+url.PathEscape(name)+"/"), &pokemonType); err != nil {
+		return Type{}, fmt.Errorf("error fetching type %q: %w", name, err)
+	}
+	return pokemonType, nil
+}
@@ -1,63 +1,399 @@
 package pokecache
 
 import (
+	"container/list"
+	"context"
+	"errors"
 	"time"
 	"sync"
 )
 
 type Cache struct {
-	CacheMap map[string]cacheEntry
+	CacheMap map[string]*cacheEntry
 	mutex    sync.RWMutex
 	duration time.Duration
+	policies []ttlPolicy
+	disk     *diskStore // nil when the cache is memory only
+
+	maxBytes   int64
+	maxEntries int
+	maxStale   time.Duration
+	bytes      int64      // size of every entry currently in CacheMap
+	lru        *list.List // keys, most recently used at the front
+
+	inflight map[string]*fetchCall // GetOrFetch calls fetching right now
+	ticker   *time.Ticker          // drives reapLoop, reset when the TTL changes
+	stop     chan struct{}         // closed by Close to end reapLoop
+	reaped   chan struct{}         // closed once reapLoop has returned
+	closed   sync.Once
+	unsaved  map[string]bool       // keys whose disk write failed, retried by Close
+
+	hits, diskHits, misses  int
+	evictions, expirations int
+	revalidations          int
+}
+
+// Options configures a Cache created with NewCacheWithOptions.
+type Options struct {
+	Duration time.Duration
+	// Policies give the entries whose key they match a TTL other than
+	// Duration, the first matching policy wins.
+	Policies []TTLPolicy
+	// Dir is the directory entries are written through to. Leave it empty
+	// for a memory only cache.
+	Dir string
+	// MaxBytes and MaxEntries bound the in-memory part of the cache; once
+	// either is exceeded the least recently used entries are evicted.
+	// Zero means no limit.
+	MaxBytes   int64
+	MaxEntries int
+	// MaxStale is how long past its TTL an entry that came with validators
+	// is kept, so it can be revalidated with a conditional request instead
+	// of fetched again. Zero drops entries as soon as they expire.
+	MaxStale time.Duration
 }
 
 func NewCache(duration time.Duration) *Cache {
-	newCacheMap := make(map[string]cacheEntry)
-	var newMutex sync.RWMutex
+	newCache, _ := NewCacheWithOptions(Options{Duration: duration}) // can't fail without a Dir
+	return newCache
+}
+
+func NewCacheWithOptions(options Options) (*Cache, error) {
+	return NewCacheContext(context.Background(), options)
+}
+
+// NewCacheContext is NewCacheWithOptions with a context bounding the
+// goroutine that expires entries: it stops when ctx is done or when the
+// cache is closed, whichever comes first. Entries stop expiring after
+// that, so ctx should live as long as the cache is used.
+func NewCacheContext(ctx context.Context, options Options) (*Cache, error) {
 	newCache := &Cache{
-		CacheMap: newCacheMap,
-		mutex: newMutex,
-		duration: duration,
+		CacheMap:   make(map[string]*cacheEntry),
+		duration:   options.Duration,
+		maxBytes:   options.MaxBytes,
+		maxEntries: options.MaxEntries,
+		maxStale:   options.MaxStale,
+		lru:        list.New(),
+		inflight:   make(map[string]*fetchCall),
+		stop:       make(chan struct{}),
+		reaped:     make(chan struct{}),
+		unsaved:    make(map[string]bool),
 	}
-	go newCache.reapLoop()
-	return newCache
+	policies, err := compilePolicies(options.Policies)
+	if err != nil {
+		return nil, err
+	}
+	newCache.policies = policies
+	if options.Dir != "" {
+		disk, err := newDiskStore(options.Dir)
+		if err != nil {
+			return nil, err
+		}
+		newCache.disk = disk
+	}
+	newCache.ticker = time.NewTicker(newCache.reapInterval())
+	go newCache.reapLoop(ctx)
+	return newCache, nil
 }
 
 type cacheEntry struct {
-	createdAt time.Time
-	val       []byte
+	createdAt  time.Time
+	ttl        time.Duration // set by AddWithTTL, 0 to go by the policies
+	val        []byte
+	validators Validators
+	element    *list.Element // position in Cache.lru
+}
+
+// size is what an entry counts against MaxBytes.
+func (e *cacheEntry) size(key string) int64 {
+	return int64(len(key) + len(e.val) + len(e.validators.ETag) + len(e.validators.LastModified))
 }
 
 func (c *Cache) Add(key string, val []byte) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	newCacheEntry := cacheEntry{
+	c.add(key, val, Validators{})
+}
+
+func (c *Cache) add(key string, val []byte, validators Validators) {
+	c.addEntry(key, &cacheEntry{
 		createdAt: time.Now(),
 		val: val,
+		validators: validators,
+	})
+}
+
+func (c *Cache) addEntry(key string, newCacheEntry *cacheEntry) {
+	c.mutex.Lock()
+	c.insert(key, newCacheEntry)
+	stored := *newCacheEntry
+	c.mutex.Unlock()
+	if c.disk == nil {
+		return
+	}
+
+	// the disk is written without the lock, so lookups aren't held up.
+	// It's best effort: a failed write only means the next session has
+	// to fetch this key again, unless Close manages to write it
+	err := c.disk.store(key, stored)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		c.unsaved[key] = true
+	} else {
+		delete(c.unsaved, key)
 	}
-	c.CacheMap[key] = newCacheEntry
 }
 
-func (c Cache) Get(key string) ([]byte, bool) {
-	c.mutex.RLock()
-	fetchedCacheEntry, ok := c.CacheMap[key]
+func (c *Cache) Get(key string) ([]byte, bool) {
+	// even a hit changes the recency order, so this takes the write lock
+	c.mutex.Lock()
+	if entry, ok := c.CacheMap[key]; ok || c.disk == nil {
+		defer c.mutex.Unlock()
+		return c.lookup(key, entry, ok)
+	}
+	c.mutex.Unlock()
+
+	// the disk is read without the lock, so memory hits aren't held up
+	diskEntry, ok := c.disk.load(key)
+
+	c.mutex.Lock()
+	if entry, inMemory := c.CacheMap[key]; inMemory || !ok {
+		// added while the disk was read, that copy is the newest one
+		defer c.mutex.Unlock()
+		return c.lookup(key, entry, inMemory)
+	}
+	if c.expired(key, &diskEntry) {
+		c.misses++
+		keep := c.worthKeeping(key, &diskEntry)
+		c.mutex.Unlock()
+		if !keep {
+			c.disk.remove(key)
+		}
+		return nil, false
+	}
+	c.hits++
+	c.diskHits++
+	c.insert(key, &diskEntry) // keeps the original createdAt so it expires on time
+	c.mutex.Unlock()
+	return diskEntry.val, true
+}
+
+// lookup counts and returns a lookup of key answered from memory, entry
+// being what's there if found. The caller must hold the write lock.
+func (c *Cache) lookup(key string, entry *cacheEntry, found bool) ([]byte, bool) {
+	if !found || c.expired(key, entry) {
+		// an expired entry is kept for revalidation, see GetOrRevalidate
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(entry.element)
+	return entry.val, true
+}
+
+// insert puts entry in memory as the most recently used one and evicts
+// whatever no longer fits. The caller must hold the write lock.
+func (c *Cache) insert(key string, entry *cacheEntry) {
+	if old, ok := c.CacheMap[key]; ok {
+		c.removeEntry(key, old)
+	}
+	if c.maxBytes > 0 && entry.size(key) > c.maxBytes {
+		return // would evict everything and still not fit
+	}
+	entry.element = c.lru.PushFront(key)
+	c.CacheMap[key] = entry
+	c.bytes += entry.size(key)
+
+	for c.overBudget() {
+		oldest := c.lru.Back()
+		oldestKey := oldest.Value.(string)
+		c.removeEntry(oldestKey, c.CacheMap[oldestKey])
+		c.evictions++
+	}
+}
+
+func (c *Cache) overBudget() bool {
+	if c.maxEntries > 0 && len(c.CacheMap) > c.maxEntries {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes > c.maxBytes
+}
+
+// removeEntry drops an entry from memory only, the disk copy is kept.
+func (c *Cache) removeEntry(key string, entry *cacheEntry) {
+	c.lru.Remove(entry.element)
+	delete(c.CacheMap, key)
+	c.bytes -= entry.size(key)
+}
+
+func (c *Cache) expired(key string, entry *cacheEntry) bool {
+	livingTime := time.Now().Sub(entry.createdAt) // amount of time the entry have been in the cache
+	return livingTime >= c.ttl(key, entry)
+}
+
+// worthKeeping reports whether entry is fresh, or expired but can still be
+// revalidated: it has validators and isn't more than MaxStale past its TTL.
+func (c *Cache) worthKeeping(key string, entry *cacheEntry) bool {
+	if !c.expired(key, entry) {
+		return true
+	}
+	return !entry.validators.empty() && time.Since(entry.createdAt) < c.ttl(key, entry)+c.maxStale
+}
+
+// staleEntry returns the expired entry kept for key in memory, or nil when
+// there is none worth revalidating. The caller must hold the write lock.
+func (c *Cache) staleEntry(key string) *cacheEntry {
+	if entry, ok := c.CacheMap[key]; ok && c.expired(key, entry) && c.worthKeeping(key, entry) {
+		return entry
+	}
+	return nil
+}
+
+// staleOnDisk is staleEntry for the disk. It reads the disk without the
+// lock, so the caller must not hold it.
+func (c *Cache) staleOnDisk(key string) *cacheEntry {
+	if c.disk == nil {
+		return nil
+	}
+	entry, ok := c.disk.load(key)
 	if !ok {
-		return nil, ok
+		return nil
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if !c.expired(key, &entry) || !c.worthKeeping(key, &entry) {
+		return nil
+	}
+	return &entry
+}
+
+// Evict drops key from memory and disk, reporting whether it was cached.
+func (c *Cache) Evict(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.CacheMap[key]
+	if ok {
+		c.removeEntry(key, entry)
 	}
-	return fetchedCacheEntry.val, ok
+	delete(c.unsaved, key)
+	if c.disk != nil && c.disk.remove(key) {
+		ok = true
+	}
+	return ok
 }
 
-func (c *Cache) reapLoop() {
-	ticker := time.NewTicker(c.duration) // ticks every "duration" of time
-	defer ticker.Stop()
-	for range ticker.C { // when a tick recieved on the channel
-			c.mutex.Lock()
-			for key, entry := range c.CacheMap {
-				livingTime := time.Now().Sub(entry.createdAt) // amount of time the entry have been in the cache
-				if livingTime >= c.duration {
-					delete(c.CacheMap, key)
-				}
-			}
-			c.mutex.Unlock()
+// Clear drops every entry from memory and disk, returning how many were
+// in memory. The statistics are kept.
+func (c *Cache) Clear() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cleared := len(c.CacheMap)
+	clear(c.CacheMap)
+	c.lru.Init()
+	c.bytes = 0
+	clear(c.unsaved)
+	if c.disk != nil {
+		c.disk.prune(func(string, cacheEntry) bool { return true })
 	}
-}
\ No newline at end of file
+	return cleared
+}
+
+// SetDuration changes how long the entries no policy covers live, the
+// ones already cached included. duration must be positive.
+func (c *Cache) SetDuration(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.duration = duration
+	c.ticker.Reset(c.reapInterval())
+}
+
+// Close stops the goroutine expiring entries and flushes the disk layer,
+// writing the entries still in memory whose write failed earlier. The
+// cache can still be read afterwards, but nothing expires any more, so
+// Close belongs at the end of a session. Closing twice is a no-op.
+func (c *Cache) Close() error {
+	var err error
+	c.closed.Do(func() {
+		close(c.stop)
+		<-c.reaped
+		err = c.flush()
+	})
+	return err
+}
+
+// flush retries the disk writes that failed.
+func (c *Cache) flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.disk == nil || len(c.unsaved) == 0 {
+		return nil
+	}
+	if err := c.disk.makeDir(); err != nil {
+		return err
+	}
+	var errs []error
+	for key := range c.unsaved {
+		entry, ok := c.CacheMap[key]
+		if !ok || !c.worthKeeping(key, entry) {
+			delete(c.unsaved, key) // evicted since, nothing worth saving
+			continue
+		}
+		if err := c.disk.store(key, *entry); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(c.unsaved, key)
+	}
+	return errors.Join(errs...)
+}
+
+// diskPruneInterval is how often reapLoop sweeps the disk for expired
+// entries. Reading every file is slow and Get already ignores expired
+// ones, so once at start and then hourly is plenty.
+const diskPruneInterval = time.Hour
+
+func (c *Cache) reapLoop(ctx context.Context) {
+	defer close(c.reaped)
+	defer c.ticker.Stop()
+	c.pruneDisk() // whatever expired while no cache was running
+	pruneTicker := time.NewTicker(diskPruneInterval)
+	defer pruneTicker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-c.ticker.C: // when a tick recieved on the channel
+			c.reap()
+		case <-pruneTicker.C:
+			c.pruneDisk()
+		}
+	}
+}
+
+// reap drops every expired entry from memory, except the ones kept for
+// revalidation.
+func (c *Cache) reap() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, entry := range c.CacheMap {
+		if !c.worthKeeping(key, entry) {
+			c.removeEntry(key, entry)
+			c.expirations++
+		}
+	}
+}
+
+// pruneDisk drops the expired entries from disk, except the ones kept for
+// revalidation. The lock is only held to check each entry, not while the
+// files are read.
+func (c *Cache) pruneDisk() {
+	if c.disk == nil {
+		return
+	}
+	c.disk.prune(func(key string, entry cacheEntry) bool {
+		c.mutex.RLock()
+		defer c.mutex.RUnlock()
+		return !c.worthKeeping(key, &entry)
+	})
+}
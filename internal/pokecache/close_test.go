@@ -0,0 +1,77 @@
+package pokecache
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitForGoroutines waits for the number of goroutines to drop back to n,
+// goroutines that were told to stop can take a moment to return.
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, %d are still running", n, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseStopsReaper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	caches := make([]*Cache, 20)
+	for i := range caches {
+		caches[i] = NewCache(time.Millisecond)
+		caches[i].Add("a", []byte("1"))
+	}
+	if running := runtime.NumGoroutine(); running < before+len(caches) {
+		t.Fatalf("expected a reaper per cache, %d goroutines for %d caches", running-before, len(caches))
+	}
+	for _, cache := range caches {
+		if err := cache.Close(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	waitForGoroutines(t, before)
+
+	// closing twice is harmless
+	if err := caches[0].Close(); err != nil {
+		t.Errorf("unexpected error closing twice: %v", err)
+	}
+}
+
+func TestContextStopsReaper(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cache, _ := NewCacheContext(ctx, Options{Duration: time.Millisecond})
+	cancel()
+	waitForGoroutines(t, before)
+	if err := cache.Close(); err != nil {
+		t.Errorf("unexpected error closing after the context ended: %v", err)
+	}
+}
+
+func TestCloseFlushesUnsavedEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer cache.Close()
+	// the directory going away makes the write through fail
+	os.RemoveAll(dir)
+	cache.Add("a", []byte("1"))
+	if val, ok := cache.Get("a"); !ok || string(val) != "1" {
+		t.Fatalf("expected a failed disk write to keep the entry in memory")
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reopened, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer reopened.Close()
+	if val, ok := reopened.Get("a"); !ok || string(val) != "1" {
+		t.Errorf("expected Close to write a to disk, got %q %v", val, ok)
+	}
+}
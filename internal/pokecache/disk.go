@@ -0,0 +1,234 @@
+package pokecache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// every entry file starts with this so we never try to decode something
+// that was not written by us (or was written by an older format: PKC1
+// entries had no validators and PKC2 ones no TTL of their own, they are
+// simply fetched again)
+const diskMagic = "PKC3"
+
+const entryFileSuffix = ".entry"
+
+var errCorruptEntry = errors.New("corrupt cache entry")
+
+// DefaultDir returns the cache directory under the user's XDG cache path
+// ($XDG_CACHE_HOME/pokedexcli, or ~/.cache/pokedexcli when it is unset).
+func DefaultDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userCacheDir, "pokedexcli"), nil
+}
+
+// diskStore keeps one file per cache key. Files are only ever read, replaced
+// or removed whole, so it's used without the Cache mutex held: the worst a
+// race can do is leave an older copy of an entry or remove one that was
+// just rewritten, and either is fetched again once it expires or is
+// missed.
+type diskStore struct {
+	dir string
+}
+
+func newDiskStore(dir string) (*diskStore, error) {
+	d := &diskStore{dir: dir}
+	if err := d.makeDir(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// makeDir creates the cache directory, or recreates it if it was removed
+// while we were running.
+func (d *diskStore) makeDir() error {
+	return os.MkdirAll(d.dir, 0o755)
+}
+
+func (d *diskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key)) // keys are URLs, so they can't be used as file names directly
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+entryFileSuffix)
+}
+
+func (d *diskStore) store(key string, entry cacheEntry) error {
+	tmp, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	// write to a temp file and rename it over the real one, so a crash
+	// mid-write leaves the old entry (or nothing) instead of half a file
+	_, err = tmp.Write(encodeEntry(key, entry))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (d *diskStore) load(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	storedKey, entry, err := decodeEntry(data)
+	if err != nil {
+		d.remove(key) // truncated or corrupt: drop it and fetch again
+		return cacheEntry{}, false
+	}
+	if storedKey != key { // hash collision, treat it as a miss
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// remove deletes the file for key, reporting whether there was one.
+func (d *diskStore) remove(key string) bool {
+	return os.Remove(d.path(key)) == nil
+}
+
+// prune deletes every entry file that is expired or whose header can't be
+// decoded, along with temp files left behind by an interrupted write. Only
+// the headers are read, the values and checksums are left for load.
+func (d *diskStore) prune(expired func(key string, entry cacheEntry) bool) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		fullPath := filepath.Join(d.dir, name)
+		if strings.HasPrefix(name, "tmp-") {
+			if info, err := file.Info(); err == nil && time.Since(info.ModTime()) > time.Minute {
+				os.Remove(fullPath)
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, entryFileSuffix) {
+			continue
+		}
+		key, entry, err := readHeader(fullPath)
+		if errors.Is(err, errCorruptEntry) || (err == nil && expired(key, entry)) {
+			os.Remove(fullPath)
+		}
+	}
+}
+
+// entry file layout:
+//
+//	magic | createdAt (unix nanos, 8 bytes) | ttl (nanos, 8 bytes, 0 when
+//	the entry has none of its own) | key length (4 bytes) | key |
+//	etag length (4 bytes) | etag | last-modified length (4 bytes) |
+//	last-modified | value length (4 bytes) | value |
+//	crc32 of everything before it (4 bytes)
+func encodeEntry(key string, entry cacheEntry) []byte {
+	etag, lastModified := entry.validators.ETag, entry.validators.LastModified
+	buf := make([]byte, 0, len(diskMagic)+8*2+4*5+len(key)+len(etag)+len(lastModified)+len(entry.val))
+	buf = append(buf, diskMagic...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(entry.createdAt.UnixNano()))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(entry.ttl))
+	for _, field := range [][]byte{[]byte(key), []byte(etag), []byte(lastModified), entry.val} {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(field)))
+		buf = append(buf, field...)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+	return buf
+}
+
+func decodeEntry(data []byte) (string, cacheEntry, error) {
+	if len(data) < 4 {
+		return "", cacheEntry{}, errCorruptEntry
+	}
+	body, checksum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != checksum {
+		return "", cacheEntry{}, errCorruptEntry
+	}
+
+	r := bytes.NewReader(body)
+	key, entry, err := decodeHeader(r, int64(len(body)))
+	if err != nil {
+		return "", cacheEntry{}, err
+	}
+	val, err := readField(r, int64(len(body)))
+	if err != nil || r.Len() != 0 {
+		return "", cacheEntry{}, errCorruptEntry
+	}
+	entry.val = val
+	return key, entry, nil
+}
+
+// readHeader decodes the header of the entry file at path, everything
+// but the value, without reading the rest of the file.
+func readHeader(path string) (string, cacheEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", cacheEntry{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", cacheEntry{}, err
+	}
+	return decodeHeader(bufio.NewReader(file), info.Size())
+}
+
+// decodeHeader reads an entry up to its value: the magic, createdAt, ttl,
+// key and validators. size bounds the field lengths it accepts.
+func decodeHeader(r io.Reader, size int64) (string, cacheEntry, error) {
+	head := make([]byte, len(diskMagic)+8*2)
+	if _, err := io.ReadFull(r, head); err != nil || string(head[:len(diskMagic)]) != diskMagic {
+		return "", cacheEntry{}, errCorruptEntry
+	}
+	createdAt := time.Unix(0, int64(binary.BigEndian.Uint64(head[len(diskMagic):])))
+	ttl := time.Duration(binary.BigEndian.Uint64(head[len(diskMagic)+8:]))
+	// key, etag and last-modified, each prefixed with its length
+	fields := make([]string, 3)
+	for i := range fields {
+		field, err := readField(r, size)
+		if err != nil {
+			return "", cacheEntry{}, err
+		}
+		fields[i] = string(field)
+	}
+	return fields[0], cacheEntry{
+		createdAt:  createdAt,
+		ttl:        ttl,
+		validators: Validators{ETag: fields[1], LastModified: fields[2]},
+	}, nil
+}
+
+// readField reads one length-prefixed field, no longer than size.
+func readField(r io.Reader, size int64) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, errCorruptEntry
+	}
+	fieldLen := int64(binary.BigEndian.Uint32(length[:]))
+	if fieldLen > size {
+		return nil, errCorruptEntry
+	}
+	field := make([]byte, fieldLen)
+	if _, err := io.ReadFull(r, field); err != nil {
+		return nil, errCorruptEntry
+	}
+	return field, nil
+}
@@ -0,0 +1,139 @@
+package pokecache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Add("https://example.com", []byte("testdata"))
+
+	// a new cache on the same directory plays the role of the next session
+	restarted, err := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	val, ok := restarted.Get("https://example.com")
+	if !ok {
+		t.Errorf("expected to find key after restart")
+		return
+	}
+	if string(val) != "testdata" {
+		t.Errorf("expected %q, got %q", "testdata", val)
+	}
+}
+
+func TestDiskHonorsExpiry(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := newDiskStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disk.store("https://example.com", cacheEntry{
+		createdAt: time.Now().Add(-time.Hour),
+		val:       []byte("testdata"),
+	})
+
+	cache, err := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected expired entry to be a miss")
+	}
+	if _, err := os.Stat(disk.path("https://example.com")); !os.IsNotExist(err) {
+		t.Errorf("expected expired entry file to be removed")
+	}
+}
+
+func TestDiskRecoversFromCorruptEntries(t *testing.T) {
+	const key = "https://example.com"
+	encoded := encodeEntry(key, cacheEntry{createdAt: time.Now(), val: []byte("testdata")})
+	flipped := append([]byte(nil), encoded...)
+	flipped[len(flipped)/2] ^= 0xff
+
+	cases := map[string][]byte{
+		"truncated":    encoded[:len(encoded)-3],
+		"empty":        {},
+		"flipped":      flipped,
+		"garbage":      []byte("not a cache entry at all"),
+		"older format": append([]byte("PKC1"), encoded[len(diskMagic):]...),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			cache, err := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			path := cache.disk.path(key)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, ok := cache.Get(key); ok {
+				t.Errorf("expected corrupt entry to be a miss")
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("expected corrupt entry file to be removed")
+			}
+
+			// and the key is usable again afterwards
+			cache.Add(key, []byte("fresh"))
+			reopened, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+			if val, ok := reopened.Get(key); !ok || string(val) != "fresh" {
+				t.Errorf("expected rewritten entry, got %q, %v", val, ok)
+			}
+		})
+	}
+}
+
+func TestDiskPrune(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := newDiskStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	disk.store("old", cacheEntry{createdAt: time.Now().Add(-time.Hour), val: []byte("a")})
+	disk.store("new", cacheEntry{createdAt: time.Now(), val: []byte("b")})
+	os.WriteFile(filepath.Join(dir, "broken"+entryFileSuffix), []byte("junk"), 0o644)
+
+	disk.prune(func(key string, entry cacheEntry) bool {
+		return time.Since(entry.createdAt) >= time.Minute
+	})
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("expected only the fresh entry to remain, found %d files", len(files))
+	}
+	if _, ok := disk.load("new"); !ok {
+		t.Errorf("expected fresh entry to survive pruning")
+	}
+}
+
+func TestOpenPrunesDisk(t *testing.T) {
+	dir := t.TempDir()
+	disk, _ := newDiskStore(dir)
+	disk.store("old", cacheEntry{createdAt: time.Now().Add(-time.Hour), val: []byte("a")})
+	disk.store("new", cacheEntry{createdAt: time.Now(), val: []byte("b")})
+
+	cache, err := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache.Close() // waits for the reaper, which prunes first thing
+
+	if _, err := os.Stat(disk.path("old")); !os.IsNotExist(err) {
+		t.Errorf("expected the expired entry to be pruned when the cache opened")
+	}
+	if _, ok := disk.load("new"); !ok {
+		t.Errorf("expected the fresh entry to survive pruning")
+	}
+}
@@ -0,0 +1,160 @@
+package pokecache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Validators identify the version of a value a server sent, so an expired
+// copy can be revalidated with a conditional request instead of fetched
+// again.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v Validators) empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// Stale is an expired value kept for revalidation.
+type Stale struct {
+	Val        []byte
+	Validators Validators
+	ttl        time.Duration // the entry's own TTL, kept when it's renewed
+}
+
+// Fetched is what a RevalidateFunc got back: a new value and its
+// validators, or NotModified when the stale value is still current.
+type Fetched struct {
+	Val         []byte
+	Validators  Validators
+	NotModified bool
+}
+
+// RevalidateFunc fetches a value. stale is the expired copy to revalidate,
+// nil when there is none and the value has to be fetched in full.
+type RevalidateFunc func(stale *Stale) (Fetched, error)
+
+// fetchCall is a fetch in progress. Callers missing the same key while it
+// runs wait on done and share its result instead of fetching again.
+type fetchCall struct {
+	done    chan struct{}
+	val     []byte
+	err     error
+	waiters int    // callers sharing the fetch besides the one running it
+	stale   *Stale // the expired value being revalidated, if any
+}
+
+// GetOrFetch returns the value cached under key, or calls fetch and adds
+// what it returns. Concurrent misses for the same key are coalesced into
+// a single fetch, and every caller gets its value or its error. Errors
+// aren't cached, the next miss after a failed fetch tries again.
+//
+// The shared fetch runs on behalf of whoever missed first, so if it stops
+// early, for example because that caller's request was cancelled, the
+// callers waiting on it get the same error.
+func (c *Cache) GetOrFetch(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	return c.GetOrRevalidate(key, func(*Stale) (Fetched, error) {
+		val, err := fetch()
+		return Fetched{Val: val}, err
+	})
+}
+
+// GetOrRevalidate is GetOrFetch for values that come with validators.
+// When key has expired but its entry was kept (see Options.MaxStale),
+// fetch is given that stale copy to make a conditional request, and a
+// NotModified answer keeps it for another TTL. While the revalidation is
+// in flight, other callers are served the stale copy instead of waiting.
+func (c *Cache) GetOrRevalidate(key string, fetch RevalidateFunc) ([]byte, error) {
+	if val, ok := c.Get(key); ok {
+		return val, nil
+	}
+
+	c.mutex.Lock()
+	if call, ok := c.inflight[key]; ok {
+		if call.stale != nil {
+			c.mutex.Unlock()
+			return call.stale.Val, nil
+		}
+		call.waiters++
+		c.mutex.Unlock()
+		<-call.done
+		return call.val, call.err
+	}
+	// a fetch for key may have finished between Get and taking the lock
+	if entry, ok := c.CacheMap[key]; ok && !c.expired(key, entry) {
+		c.lru.MoveToFront(entry.element)
+		c.mutex.Unlock()
+		return entry.val, nil
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	_, inMemory := c.CacheMap[key]
+	if stale := c.staleEntry(key); stale != nil {
+		call.stale = &Stale{Val: stale.val, Validators: stale.validators, ttl: stale.ttl}
+	}
+	c.inflight[key] = call
+	c.mutex.Unlock()
+	if !inMemory {
+		// callers arriving before this is set wait for the fetch instead
+		// of being served the stale copy
+		if stale := c.staleOnDisk(key); stale != nil {
+			c.mutex.Lock()
+			call.stale = &Stale{Val: stale.val, Validators: stale.validators, ttl: stale.ttl}
+			c.mutex.Unlock()
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			// the waiters get an error instead of blocking forever
+			call.err = fmt.Errorf("fetching %s panicked: %v", key, r)
+			c.finishFetch(key, call)
+			panic(r)
+		}
+	}()
+	fetched, err := fetch(call.stale)
+	switch {
+	case err != nil:
+		call.err = err
+	case fetched.NotModified && call.stale == nil:
+		call.err = fmt.Errorf("%s was not modified, but there is no cached copy of it", key)
+	case fetched.NotModified:
+		call.val = call.stale.Val
+		c.revalidated(key, call.stale, fetched.Validators)
+	default:
+		call.val = fetched.Val
+		c.add(key, fetched.Val, fetched.Validators)
+	}
+	c.finishFetch(key, call)
+	return call.val, call.err
+}
+
+// revalidated renews a stale entry the server said is still current,
+// taking any validators it sent along instead of the old ones. A TTL the
+// entry was added with is kept.
+func (c *Cache) revalidated(key string, stale *Stale, validators Validators) {
+	if validators.ETag == "" {
+		validators.ETag = stale.Validators.ETag
+	}
+	if validators.LastModified == "" {
+		validators.LastModified = stale.Validators.LastModified
+	}
+	c.addEntry(key, &cacheEntry{
+		createdAt:  time.Now(),
+		ttl:        stale.ttl,
+		val:        stale.Val,
+		validators: validators,
+	})
+	c.mutex.Lock()
+	c.revalidations++
+	c.mutex.Unlock()
+}
+
+// finishFetch wakes up the callers waiting on call.
+func (c *Cache) finishFetch(key string, call *fetchCall) {
+	c.mutex.Lock()
+	delete(c.inflight, key)
+	c.mutex.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,171 @@
+package pokecache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n callers are waiting on the fetch for key.
+func waitForWaiters(t *testing.T, cache *Cache, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		cache.mutex.Lock()
+		call, ok := cache.inflight[key]
+		waiting := ok && call.waiters == n
+		cache.mutex.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d callers to wait on %s", n, key)
+}
+
+// fetchConcurrently calls GetOrFetch for key from n goroutines once the
+// first of them is fetching, and returns what each one got.
+func fetchConcurrently(t *testing.T, cache *Cache, key string, n int, fetch func() ([]byte, error)) ([][]byte, []error) {
+	t.Helper()
+	release := make(chan struct{})
+	var started sync.Once
+	startedFetching := make(chan struct{})
+	blocking := func() ([]byte, error) {
+		started.Do(func() { close(startedFetching) })
+		<-release
+		return fetch()
+	}
+
+	vals := make([][]byte, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i > 0 {
+				<-startedFetching
+			}
+			vals[i], errs[i] = cache.GetOrFetch(key, blocking)
+		}()
+	}
+	<-startedFetching
+	waitForWaiters(t, cache, key, n-1)
+	close(release)
+	wg.Wait()
+	return vals, errs
+}
+
+func TestGetOrFetchCoalesces(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	var fetches atomic.Int32
+	vals, errs := fetchConcurrently(t, cache, "pokemon/pikachu", 50, func() ([]byte, error) {
+		fetches.Add(1)
+		return []byte("pikachu"), nil
+	})
+
+	if fetches.Load() != 1 {
+		t.Errorf("expected a single fetch, got %d", fetches.Load())
+	}
+	for i := range vals {
+		if errs[i] != nil || string(vals[i]) != "pikachu" {
+			t.Errorf("caller %d: expected pikachu, got %q (%v)", i, vals[i], errs[i])
+		}
+	}
+	if val, ok := cache.Get("pokemon/pikachu"); !ok || string(val) != "pikachu" {
+		t.Errorf("expected the fetched value to be cached once, got %q", val)
+	}
+}
+
+func TestGetOrFetchSharesErrors(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	errBoom := errors.New("boom")
+	var fetches atomic.Int32
+	_, errs := fetchConcurrently(t, cache, "pokemon/missingno", 20, func() ([]byte, error) {
+		fetches.Add(1)
+		return nil, errBoom
+	})
+
+	for i, err := range errs {
+		if !errors.Is(err, errBoom) {
+			t.Errorf("caller %d: expected the fetch error, got %v", i, err)
+		}
+	}
+	if fetches.Load() != 1 {
+		t.Errorf("expected a single fetch, got %d", fetches.Load())
+	}
+	// errors aren't cached
+	val, err := cache.GetOrFetch("pokemon/missingno", func() ([]byte, error) { return []byte("found"), nil })
+	if err != nil || string(val) != "found" {
+		t.Errorf("expected a retry after the failed fetch, got %q (%v)", val, err)
+	}
+}
+
+func TestGetOrFetchHit(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	cache.Add("pokemon/eevee", []byte("eevee"))
+	val, err := cache.GetOrFetch("pokemon/eevee", func() ([]byte, error) {
+		t.Errorf("expected no fetch for a cached key")
+		return nil, nil
+	})
+	if err != nil || string(val) != "eevee" {
+		t.Errorf("expected eevee, got %q (%v)", val, err)
+	}
+}
+
+func TestGetOrFetchDifferentKeys(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	var fetches atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := []string{"a", "b", "c", "d"}[i%4]
+			cache.GetOrFetch(key, func() ([]byte, error) {
+				fetches.Add(1)
+				return []byte(key), nil
+			})
+		}()
+	}
+	wg.Wait()
+	// a key is either being fetched, and joined, or already cached
+	if fetches.Load() != 4 {
+		t.Errorf("expected one fetch per key, got %d", fetches.Load())
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if val, ok := cache.Get(key); !ok || string(val) != key {
+			t.Errorf("expected %s to be cached, got %q", key, val)
+		}
+	}
+}
+
+func TestGetOrFetchPanic(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	release := make(chan struct{})
+	waiterErr := make(chan error)
+	go func() {
+		defer func() { recover() }()
+		cache.GetOrFetch("pokemon/ditto", func() ([]byte, error) {
+			<-release
+			panic("transform failed")
+		})
+	}()
+	waitForWaiters(t, cache, "pokemon/ditto", 0)
+	go func() {
+		_, err := cache.GetOrFetch("pokemon/ditto", func() ([]byte, error) { return nil, nil })
+		waiterErr <- err
+	}()
+	waitForWaiters(t, cache, "pokemon/ditto", 1)
+	close(release)
+	if err := <-waiterErr; err == nil {
+		t.Errorf("expected the waiter to get an error from the panicked fetch")
+	}
+}
@@ -0,0 +1,145 @@
+package pokecache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keysInOrder returns the cached keys from most to least recently used.
+func keysInOrder(c *Cache) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var keys []string
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(string))
+	}
+	return keys
+}
+
+func TestLRUEvictsByEntries(t *testing.T) {
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, MaxEntries: 3})
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+	cache.Add("c", []byte("3"))
+	cache.Get("a") // a is now the most recently used, b the least
+	cache.Add("d", []byte("4"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	expected := []string{"d", "a", "c"}
+	if got := keysInOrder(cache); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
+
+func TestLRUEvictsByBytes(t *testing.T) {
+	// every entry below is 1 byte of key plus 4 bytes of value
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, MaxBytes: 12})
+	defer cache.Close()
+	cache.Add("a", []byte("aaaa"))
+	cache.Add("b", []byte("bbbb"))
+	cache.Add("c", []byte("cccc"))
+
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected a to be evicted")
+	}
+	if cache.bytes != 10 {
+		t.Errorf("expected 10 bytes stored, got %d", cache.bytes)
+	}
+
+	// replacing a key must not count it twice
+	cache.Add("c", []byte("cc"))
+	if cache.bytes != 8 {
+		t.Errorf("expected 8 bytes stored, got %d", cache.bytes)
+	}
+
+	// an entry bigger than the whole budget is not kept and evicts nothing
+	cache.Add("huge", []byte("0123456789"))
+	if _, ok := cache.Get("huge"); ok {
+		t.Errorf("expected oversized entry not to be cached")
+	}
+	if len(cache.CacheMap) != 2 {
+		t.Errorf("expected 2 entries to remain, got %d", len(cache.CacheMap))
+	}
+}
+
+func TestLRUConcurrentAddGet(t *testing.T) {
+	const maxEntries = 8
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, MaxEntries: maxEntries})
+	defer cache.Close()
+	cache.Add("hot", []byte("keep me"))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				// every add is preceded by touching the hot key, so at most
+				// one key per writer can be ahead of it when an eviction
+				// happens and it must never be the least recently used
+				cache.Get("hot")
+				cache.Add(fmt.Sprintf("w%d-%d", w, i), []byte("x"))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if len(cache.CacheMap) > maxEntries {
+		t.Errorf("expected at most %d entries, got %d", maxEntries, len(cache.CacheMap))
+	}
+	if cache.lru.Len() != len(cache.CacheMap) {
+		t.Errorf("lru list has %d keys but the map has %d", cache.lru.Len(), len(cache.CacheMap))
+	}
+	if _, ok := cache.Get("hot"); !ok {
+		t.Errorf("expected the hot key to survive eviction")
+	}
+	for _, key := range keysInOrder(cache) {
+		if _, ok := cache.CacheMap[key]; !ok {
+			t.Errorf("lru list references evicted key %q", key)
+		}
+	}
+}
+
+func TestLRUConcurrentEvictionOrder(t *testing.T) {
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, MaxEntries: 4})
+	defer cache.Close()
+
+	// four writers race to fill the cache, then the order is fixed by
+	// reading back in a known sequence before one more add
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			cache.Add(key, []byte(key))
+		}(key)
+	}
+	wg.Wait()
+
+	for _, key := range []string{"c", "a", "d", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				cache.Get(key)
+			}
+		}(key)
+	}
+	wg.Wait()
+	for _, key := range []string{"c", "a", "d", "b"} {
+		cache.Get(key)
+	}
+
+	cache.Add("e", []byte("e"))
+	cache.Add("f", []byte("f"))
+
+	expected := []string{"f", "e", "b", "d"}
+	if got := keysInOrder(cache); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
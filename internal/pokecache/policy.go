@@ -0,0 +1,82 @@
+package pokecache
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// TTLPolicy gives the entries whose key matches Pattern, a regular
+// expression, their own TTL instead of the cache's duration.
+type TTLPolicy struct {
+	Pattern string
+	TTL     time.Duration
+}
+
+// ttlPolicy is a TTLPolicy with its pattern compiled.
+type ttlPolicy struct {
+	TTLPolicy
+	pattern *regexp.Regexp
+}
+
+func compilePolicies(policies []TTLPolicy) ([]ttlPolicy, error) {
+	compiled := make([]ttlPolicy, len(policies))
+	for i, policy := range policies {
+		if policy.TTL <= 0 {
+			return nil, fmt.Errorf("TTL policy %q: the TTL must be positive", policy.Pattern)
+		}
+		pattern, err := regexp.Compile(policy.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("TTL policy %q: %w", policy.Pattern, err)
+		}
+		compiled[i] = ttlPolicy{TTLPolicy: policy, pattern: pattern}
+	}
+	return compiled, nil
+}
+
+// AddWithTTL is Add with a TTL for this entry alone, taking precedence
+// over the policies and the cache's duration. It's kept on disk with the
+// entry.
+func (c *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	c.addEntry(key, &cacheEntry{
+		createdAt: time.Now(),
+		val:       val,
+		ttl:       ttl,
+	})
+}
+
+// Policies returns the TTL policies the cache was created with.
+func (c *Cache) Policies() []TTLPolicy {
+	policies := make([]TTLPolicy, len(c.policies))
+	for i, policy := range c.policies {
+		policies[i] = policy.TTLPolicy
+	}
+	return policies
+}
+
+// ttl is how long the entry under key lives: its own TTL if it was added
+// with one, else the TTL of the first policy matching key, else the
+// cache's duration. The caller must hold the lock.
+func (c *Cache) ttl(key string, entry *cacheEntry) time.Duration {
+	if entry.ttl > 0 {
+		return entry.ttl
+	}
+	for _, policy := range c.policies {
+		if policy.pattern.MatchString(key) {
+			return policy.TTL
+		}
+	}
+	return c.duration
+}
+
+// reapInterval is how often reapLoop runs: often enough for the shortest
+// TTL the policies or the cache's duration give. Entries added with a
+// shorter TTL of their own are still never served once expired, they
+// only linger in memory until the next reap.
+func (c *Cache) reapInterval() time.Duration {
+	interval := c.duration
+	for _, policy := range c.policies {
+		interval = min(interval, policy.TTL)
+	}
+	return interval
+}
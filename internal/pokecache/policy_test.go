@@ -0,0 +1,103 @@
+package pokecache
+
+import (
+	"testing"
+	"time"
+)
+
+// ttls returns the TTL of every entry in memory by key.
+func ttls(cache *Cache) map[string]time.Duration {
+	ttls := make(map[string]time.Duration)
+	for _, entry := range cache.Entries() {
+		ttls[entry.Key] = entry.TTL
+	}
+	return ttls
+}
+
+func TestPolicies(t *testing.T) {
+	cache, err := NewCacheWithOptions(Options{
+		Duration: time.Minute,
+		Policies: []TTLPolicy{
+			{Pattern: `/location-area/?\?`, TTL: 30 * 24 * time.Hour},
+			{Pattern: `/pokemon/`, TTL: 7 * 24 * time.Hour},
+			{Pattern: `/pokemon/.*`, TTL: time.Second}, // never reached, the one above wins
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cache.Close()
+	cache.Add("https://pokeapi.co/api/v2/location-area?offset=0&limit=20", []byte("list"))
+	cache.Add("https://pokeapi.co/api/v2/pokemon/pikachu/", []byte("pikachu"))
+	cache.Add("https://pokeapi.co/api/v2/move/growl/", []byte("growl"))
+	cache.AddWithTTL("https://pokeapi.co/api/v2/pokemon/eevee/", []byte("eevee"), time.Hour)
+
+	expected := map[string]time.Duration{
+		"https://pokeapi.co/api/v2/location-area?offset=0&limit=20": 30 * 24 * time.Hour,
+		"https://pokeapi.co/api/v2/pokemon/pikachu/":                7 * 24 * time.Hour,
+		"https://pokeapi.co/api/v2/move/growl/":                     time.Minute,
+		"https://pokeapi.co/api/v2/pokemon/eevee/":                  time.Hour,
+	}
+	got := ttls(cache)
+	for key, ttl := range expected {
+		if got[key] != ttl {
+			t.Errorf("%s: expected a TTL of %v, got %v", key, ttl, got[key])
+		}
+	}
+
+	// the policies don't follow the cache's duration
+	cache.SetDuration(2 * time.Minute)
+	if ttl := ttls(cache)["https://pokeapi.co/api/v2/pokemon/pikachu/"]; ttl != 7*24*time.Hour {
+		t.Errorf("expected pikachu to keep its policy's TTL, got %v", ttl)
+	}
+}
+
+func TestReapHonorsEntryTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions(Options{
+		Duration: time.Hour,
+		Policies: []TTLPolicy{{Pattern: `^short/`, TTL: 10 * time.Millisecond}},
+	})
+	defer cache.Close()
+	cache.Add("short/a", []byte("1"))
+	cache.Add("long/b", []byte("2"))
+	cache.AddWithTTL("long/c", []byte("3"), 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	// the reaper runs as often as the shortest policy needs, an entry's
+	// own shorter TTL is honored when it's looked up
+	if _, ok := cache.Get("long/c"); ok {
+		t.Errorf("expected long/c to expire with its own TTL")
+	}
+	if _, ok := cache.Get("long/b"); !ok {
+		t.Errorf("expected long/b to live as long as the cache's duration")
+	}
+	if stats := cache.Stats(); stats.Expirations < 2 || stats.Entries != 1 {
+		t.Errorf("expected short/a and long/c to be reaped, got %+v", stats)
+	}
+}
+
+func TestAddWithTTLSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer cache.Close()
+	cache.AddWithTTL("pokemon/pikachu", []byte("pikachu"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	restarted, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer restarted.Close()
+	if _, ok := restarted.Get("pokemon/pikachu"); ok {
+		t.Errorf("expected the entry's own TTL to be kept on disk")
+	}
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	cases := map[string]TTLPolicy{
+		"bad pattern": {Pattern: `pokemon/(`, TTL: time.Hour},
+		"no ttl":      {Pattern: `pokemon/`},
+	}
+	for name, policy := range cases {
+		if _, err := NewCacheWithOptions(Options{Duration: time.Minute, Policies: []TTLPolicy{policy}}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
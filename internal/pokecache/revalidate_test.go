@@ -0,0 +1,158 @@
+package pokecache
+
+import (
+	"testing"
+	"time"
+)
+
+var pikachuV1 = Validators{ETag: `"v1"`, LastModified: "Wed, 01 Jan 2025 12:00:00 GMT"}
+
+// fetchWith returns a RevalidateFunc that answers with fetched and records
+// the stale copy it was given.
+func fetchWith(fetched Fetched, given **Stale) RevalidateFunc {
+	return func(stale *Stale) (Fetched, error) {
+		*given = stale
+		return fetched, nil
+	}
+}
+
+// newStaleCache returns a cache holding pikachu with pikachuV1 validators,
+// already expired.
+func newStaleCache(t *testing.T, options Options) *Cache {
+	t.Helper()
+	options.Duration = 50 * time.Millisecond
+	options.MaxStale = time.Minute
+	cache, err := NewCacheWithOptions(options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	var given *Stale
+	cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{Val: []byte("pikachu"), Validators: pikachuV1}, &given))
+	time.Sleep(70 * time.Millisecond)
+	return cache
+}
+
+func TestStaleEntriesAreKept(t *testing.T) {
+	cache := newStaleCache(t, Options{})
+	cache.Add("pokemon/eevee", []byte("eevee")) // no validators
+	time.Sleep(130 * time.Millisecond)          // its TTL and the next reap
+
+	for _, key := range []string{"pokemon/pikachu", "pokemon/eevee"} {
+		if _, ok := cache.Get(key); ok {
+			t.Errorf("expected %s to have expired", key)
+		}
+	}
+	entries := cache.Entries()
+	if len(entries) != 1 || entries[0].Key != "pokemon/pikachu" || !entries[0].Stale {
+		t.Errorf("expected only pikachu to be kept, stale, got %+v", entries)
+	}
+}
+
+func TestRevalidateNotModified(t *testing.T) {
+	cache := newStaleCache(t, Options{})
+	var given *Stale
+	val, err := cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{NotModified: true}, &given))
+	if err != nil || string(val) != "pikachu" {
+		t.Fatalf("expected the stale copy, got %q (%v)", val, err)
+	}
+	if given == nil || given.Validators != pikachuV1 {
+		t.Errorf("expected fetch to get the stale copy's validators, got %+v", given)
+	}
+	// a 304 refreshes the entry for another TTL
+	if val, ok := cache.Get("pokemon/pikachu"); !ok || string(val) != "pikachu" {
+		t.Errorf("expected the revalidated entry to be fresh, got %q %v", val, ok)
+	}
+	if revalidations := cache.Stats().Revalidations; revalidations != 1 {
+		t.Errorf("expected 1 revalidation, got %d", revalidations)
+	}
+}
+
+func TestRevalidateKeepsEntryTTL(t *testing.T) {
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Hour, MaxStale: time.Minute})
+	defer cache.Close()
+	cache.addEntry("pokemon/pikachu", &cacheEntry{
+		createdAt:  time.Now().Add(-time.Second),
+		ttl:        500 * time.Millisecond,
+		val:        []byte("pikachu"),
+		validators: pikachuV1,
+	})
+	var given *Stale
+	if _, err := cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{NotModified: true}, &given)); err != nil || given == nil {
+		t.Fatalf("expected a revalidation, got %v", err)
+	}
+	if ttl := ttls(cache)["pokemon/pikachu"]; ttl != 500*time.Millisecond {
+		t.Errorf("expected the entry to keep its own TTL, got %v", ttl)
+	}
+}
+
+func TestRevalidateModified(t *testing.T) {
+	cache := newStaleCache(t, Options{})
+	v2 := Validators{ETag: `"v2"`}
+	var given *Stale
+	val, err := cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{Val: []byte("pikachu v2"), Validators: v2}, &given))
+	if err != nil || string(val) != "pikachu v2" {
+		t.Fatalf("expected the new value, got %q (%v)", val, err)
+	}
+	if given == nil {
+		t.Errorf("expected fetch to get the stale copy")
+	}
+	time.Sleep(70 * time.Millisecond)
+	cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{NotModified: true}, &given))
+	if given == nil || given.Validators != v2 {
+		t.Errorf("expected the new validators to be kept, got %+v", given)
+	}
+}
+
+func TestServeStaleWhileRevalidating(t *testing.T) {
+	cache := newStaleCache(t, Options{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.GetOrRevalidate("pokemon/pikachu", func(stale *Stale) (Fetched, error) {
+			<-release
+			return Fetched{Val: []byte("pikachu v2")}, nil
+		})
+	}()
+	waitForWaiters(t, cache, "pokemon/pikachu", 0)
+
+	val, err := cache.GetOrRevalidate("pokemon/pikachu", func(*Stale) (Fetched, error) {
+		t.Errorf("expected no second fetch while revalidating")
+		return Fetched{}, nil
+	})
+	if err != nil || string(val) != "pikachu" {
+		t.Errorf("expected the stale copy while revalidating, got %q (%v)", val, err)
+	}
+	close(release)
+	<-done
+	if val, _ := cache.Get("pokemon/pikachu"); string(val) != "pikachu v2" {
+		t.Errorf("expected the revalidated value afterwards, got %q", val)
+	}
+}
+
+func TestNotModifiedWithoutCopy(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	var given *Stale
+	if _, err := cache.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{NotModified: true}, &given)); err == nil {
+		t.Errorf("expected an error for a 304 with nothing cached")
+	}
+}
+
+func TestRevalidateFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	newStaleCache(t, Options{Dir: dir})
+
+	// the next session finds the stale entry on disk, validators included
+	restarted, _ := NewCacheWithOptions(Options{Duration: 50 * time.Millisecond, MaxStale: time.Minute, Dir: dir})
+	defer restarted.Close()
+	var given *Stale
+	val, err := restarted.GetOrRevalidate("pokemon/pikachu", fetchWith(Fetched{NotModified: true}, &given))
+	if err != nil || string(val) != "pikachu" {
+		t.Fatalf("expected the stale copy, got %q (%v)", val, err)
+	}
+	if given == nil || given.Validators != pikachuV1 {
+		t.Errorf("expected the validators to survive a restart, got %+v", given)
+	}
+}
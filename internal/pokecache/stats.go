@@ -0,0 +1,70 @@
+package pokecache
+
+import "time"
+
+// Stats is a snapshot of a Cache's contents and of how it has been used
+// since it was created.
+type Stats struct {
+	Hits     int // lookups answered from memory or disk
+	DiskHits int // the part of Hits that had to be read back from disk
+	Misses   int
+	// Evictions are entries dropped to stay within MaxBytes or MaxEntries,
+	// Expirations the ones dropped for outliving the TTL.
+	Evictions   int
+	Expirations int
+	// Revalidations are expired entries the server said were unchanged.
+	Revalidations int
+
+	Entries    int   // entries in memory
+	Bytes      int64 // their size, as counted against MaxBytes
+	MaxBytes   int64
+	MaxEntries int
+	TTL        time.Duration // for the entries no policy covers
+}
+
+// EntryInfo describes one cached entry.
+type EntryInfo struct {
+	Key   string
+	Size  int64
+	Age   time.Duration
+	TTL   time.Duration // its own, its policy's or the cache's
+	Stale bool          // expired, kept to be revalidated
+}
+
+func (c *Cache) Stats() Stats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return Stats{
+		Hits:          c.hits,
+		DiskHits:      c.diskHits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Expirations:   c.expirations,
+		Revalidations: c.revalidations,
+		Entries:       len(c.CacheMap),
+		Bytes:         c.bytes,
+		MaxBytes:      c.maxBytes,
+		MaxEntries:    c.maxEntries,
+		TTL:           c.duration,
+	}
+}
+
+// Entries lists the entries in memory, most recently used first.
+func (c *Cache) Entries() []EntryInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	now := time.Now()
+	entries := make([]EntryInfo, 0, len(c.CacheMap))
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		key := element.Value.(string)
+		entry := c.CacheMap[key]
+		entries = append(entries, EntryInfo{
+			Key:   key,
+			Size:  entry.size(key),
+			Age:   now.Sub(entry.createdAt),
+			TTL:   c.ttl(key, entry),
+			Stale: c.expired(key, entry),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,124 @@
+package pokecache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, MaxEntries: 2})
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("22"))
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+	cache.Add("c", []byte("333")) // evicts b
+
+	stats := cache.Stats()
+	expected := Stats{
+		Hits:       2,
+		Misses:     1,
+		Evictions:  1,
+		Entries:    2,
+		Bytes:      6,
+		MaxEntries: 2,
+		TTL:        time.Minute,
+	}
+	if stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestStatsCountsExpirations(t *testing.T) {
+	cache := NewCache(5 * time.Millisecond)
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	time.Sleep(20 * time.Millisecond)
+	if stats := cache.Stats(); stats.Expirations != 1 || stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("expected the entry to expire, got %+v", stats)
+	}
+}
+
+func TestStatsCountsDiskHits(t *testing.T) {
+	dir := t.TempDir()
+	first, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer first.Close()
+	first.Add("a", []byte("1"))
+	second, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer second.Close()
+	second.Get("a")
+	second.Get("a")
+	if stats := second.Stats(); stats.Hits != 2 || stats.DiskHits != 1 {
+		t.Errorf("expected 2 hits, 1 of them from disk, got %+v", stats)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("bb", []byte("22"))
+	cache.Get("a")
+
+	entries := cache.Entries()
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+		if entry.Age < 0 || entry.Age > time.Minute {
+			t.Errorf("%s: unexpected age %v", entry.Key, entry.Age)
+		}
+	}
+	if fmt.Sprint(keys) != "[a bb]" {
+		t.Errorf("expected the most recently used first, got %v", keys)
+	}
+	if entries[1].Size != 4 {
+		t.Errorf("expected bb to take 4 bytes, got %d", entries[1].Size)
+	}
+}
+
+func TestEvictAndClear(t *testing.T) {
+	dir := t.TempDir()
+	cache, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.Add("b", []byte("2"))
+	cache.Add("c", []byte("3"))
+
+	if !cache.Evict("a") {
+		t.Errorf("expected a to be evicted")
+	}
+	if cache.Evict("a") {
+		t.Errorf("expected a to be gone already")
+	}
+	if cleared := cache.Clear(); cleared != 2 {
+		t.Errorf("expected 2 entries cleared, got %d", cleared)
+	}
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("expected an empty cache, got %+v", stats)
+	}
+
+	// nothing is left on disk for the next session either
+	reopened, _ := NewCacheWithOptions(Options{Duration: time.Minute, Dir: dir})
+	defer reopened.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok := reopened.Get(key); ok {
+			t.Errorf("expected %s to be removed from disk", key)
+		}
+	}
+}
+
+func TestSetDuration(t *testing.T) {
+	cache := NewCache(time.Hour)
+	defer cache.Close()
+	cache.Add("a", []byte("1"))
+	cache.SetDuration(5 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("expected a shorter TTL to expire the entry")
+	}
+	if ttl := cache.Stats().TTL; ttl != 5*time.Millisecond {
+		t.Errorf("expected the TTL to be 5ms, got %v", ttl)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// newCaughtPokemon turns the wild Pokemon that was just caught into one
+// the player owns, keeping the level, individual values and nature it
+// was met with.
+func newCaughtPokemon(pokemon pokeapi.Pokemon, species pokeapi.PokemonSpecies, wild wildEncounter, at time.Time) CaughtPokemon {
+	rate := battle.GrowthRate(species.GrowthRate.Name)
+	return CaughtPokemon{
+		Name:       pokemon.Name,
+		Species:    pokemon.Species.Name,
+		CaughtAt:   at,
+		Location:   wild.Area,
+		Level:      wild.Level,
+		Experience: battle.ExperienceAt(rate, wild.Level),
+		GrowthRate: rate,
+		Nature:     wild.Nature,
+		IVs:        wild.IVs,
+		Friendship: species.BaseHappiness,
+	}
+}
+
+// ActualStats are p's stats at its level, what it battles with. pokemon
+// is p's PokeAPI data.
+func (p CaughtPokemon) ActualStats(pokemon pokeapi.Pokemon) battle.Stats {
+	nature, _ := battle.NatureByName(p.Nature)
+	return battle.CalcStats(baseStats(pokemon), p.IVs, p.EVs, p.Level, nature)
+}
+
+// wildStats are the stats of the wild Pokemon in front of the player,
+// which has individual values and a nature but no effort values yet.
+func wildStats(pokemon pokeapi.Pokemon, wild wildEncounter) battle.Stats {
+	nature, _ := battle.NatureByName(wild.Nature)
+	return battle.CalcStats(baseStats(pokemon), wild.IVs, battle.Stats{}, wild.Level, nature)
+}
+
+// defeat rewards p for defeating defeated at level with experience and
+// effort values. It returns the experience gained and the level p was at
+// before.
+func (p *CaughtPokemon) defeat(defeated pokeapi.Pokemon, level int) (int, int) {
+	gained := battle.ExperienceYield(defeated.BaseExperience, level)
+	before := p.Level
+	p.EVs = p.EVs.AddEffort(effortYield(defeated))
+	p.Experience = min(p.Experience+gained, battle.ExperienceAt(p.GrowthRate, battle.MaxLevel))
+	p.Level = max(p.Level, battle.LevelFor(p.GrowthRate, p.Experience))
+	for range p.Level - before {
+		p.Friendship = min(p.Friendship+friendshipGain(p.Friendship), maxFriendship)
+	}
+	return gained, before
+}
+
+// friendshipGain is how much friendlier levelling up makes a Pokemon, less
+// the friendlier it already is.
+func friendshipGain(friendship int) int {
+	switch {
+	case friendship < 100:
+		return 5
+	case friendship < 200:
+		return 3
+	}
+	return 2
+}
+
+// toNextLevel is the experience p still needs to level up, 0 at the
+// highest level.
+func (p CaughtPokemon) toNextLevel() int {
+	if p.Level >= battle.MaxLevel {
+		return 0
+	}
+	return battle.ExperienceAt(p.GrowthRate, p.Level+1) - p.Experience
+}
+
+func baseStats(pokemon pokeapi.Pokemon) battle.Stats {
+	var stats battle.Stats
+	for _, item := range pokemon.Stats {
+		stats.Set(item.Stat.Name, item.BaseStat)
+	}
+	return stats
+}
+
+// effortYield is the effort values defeating pokemon gives.
+func effortYield(pokemon pokeapi.Pokemon) battle.Stats {
+	var stats battle.Stats
+	for _, item := range pokemon.Stats {
+		stats.Set(item.Stat.Name, item.Effort)
+	}
+	return stats
+}
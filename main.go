@@ -1,82 +1,210 @@
 package main
 
 import ( 
+	"flag"
 	"fmt"
-	"strings"
-	"bufio"
+	"math/rand"
+	"net/http"
+	"path/filepath"
 	"os"
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
 	"github.com/OmarJarbou/pokedexcli/internal/pokecache"
 	"time"
 )
 
 func main() {
-	initURL := "https://pokeapi.co/api/v2/location-area?offset=0&limit=20"
+	profileName := flag.String("profile", "", "trainer profile to play as (default: the one used last)")
+	outputName := flag.String("output", "text", "output format of map, explore, inspect and pokedex: text, json, yaml or csv")
+	verbose := flag.Bool("verbose", false, "print cache diagnostics to stderr")
+	offline := flag.Bool("offline", false, "never use the network, answer from the --bundle directory")
+	bundleDir := flag.String("bundle", "", "directory of recorded responses used by --offline (default: bundle in the data directory)")
+	recordPath := flag.String("record", "", "log every PokeAPI request and response to this JSONL file, e.g. requests.jsonl")
+	replayPath := flag.String("replay", "", "serve every PokeAPI request from a session log written by --record")
+	scriptPath := flag.String("script", "", "run the commands in this file, one per line, instead of the REPL (- reads stdin)")
+	timeout := flag.Duration("timeout", defaultTimeout, "how long a PokeAPI request may take, 0 for no limit")
+	retries := flag.Int("retries", 3, "how many times a PokeAPI request failing with a network error, 429 or 5xx is retried")
+	rateLimit := flag.Float64("rate-limit", 10, "most PokeAPI requests per second, 0 for no limit")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: pokedexcli [flags] [command [arguments...]]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Starts the REPL, or runs a single command when one is given.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	output, err := parseOutputFormat(*outputName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	// a bad name is refused up front, playing on without a save file would
+	// quietly lose the progress
+	requestedProfile, err := profileFlag(*profileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	retry := pokeapi.RetryOptions{
+		MaxRetries: max(*retries, 0),
+		Limiter:    pokeapi.NewLimiter(*rateLimit, rateLimitBurst),
+	}
+	transport, closeRecording, err := newTransport(*offline, *bundleDir, *recordPath, *replayPath, retry)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	// recording and replaying need every request to reach the transport in
+	// the same order, so responses from earlier sessions are left out, and
+	// bundle responses must not end up in the cache online sessions read
+	persistentCache := *recordPath == "" && *replayPath == "" && !*offline
+	client := pokeapi.NewClient(pokeapi.Options{
+		HTTPClient: &http.Client{Transport: transport},
+		Cache:      newCache(5 * time.Second, persistentCache),
+		Timeout:    *timeout,
+	})
+	if *verbose {
+		client.SetDebug(os.Stderr)
+	}
+
+	initURL := client.LocationAreaPageURL(0, 20)
 	config := Config{
 		Next: &initURL,
 		Previous: nil,
+		Client: client,
+		Output: output,
+		Storage: NewStorage(),
+		Rand: rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	commands := Commands(&config)
 
-	var cache *pokecache.Cache
-	cache = pokecache.NewCache(5 * time.Second)
+	pokedex := NewPokedex()
+	dir, err := dataDir()
+	if err == nil {
+		config.Profiles, err = openProfiles(dir, requestedProfile)
+	}
+	if err == nil {
+		err = openProfile(&config, &pokedex, config.Profiles.Current)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "playing without a save file: " + err.Error())
+	}
 
-	pokedex := Pokedex{
-		Items: make(map[string]Pokemon),
+	var exitCode int
+	switch {
+	case flag.NArg() > 0 && *scriptPath != "":
+		fmt.Fprintln(os.Stderr, "a one-shot command can't be combined with --script")
+		exitCode = exitUsage
+	case flag.NArg() > 0:
+		exitCode = runOneShot(commands, &config, &pokedex, flag.Args())
+	case *scriptPath != "":
+		exitCode = runScriptFile(commands, &config, &pokedex, *scriptPath)
+	default:
+		runREPL(commands, &config, &pokedex)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin) 
-	
-	for programStartingREPL(scanner) {
-		var inputString string
-		var inputWords [] string
-		
-		inputString = scanner.Text() // get the line we read as a string
+	autosave(&config, &pokedex)
+	closeCache(&config)
+	if err := closeRecording(); err != nil {
+		fmt.Fprintln(os.Stderr, "error closing session log: " + err.Error())
+	}
+	os.Exit(exitCode)
+}
 
-		inputWords = cleanInput(inputString)
+// defaultTimeout is how long a request may take unless --timeout says
+// otherwise, Ctrl-C gives up on one sooner.
+const defaultTimeout = 10 * time.Second
 
-		// fmt.Println("Your command was: " + inputWords[0])
+// rateLimitBurst is how many requests can go out at once before
+// --rate-limit kicks in, enough for a catch or a battle to start quickly.
+const rateLimitBurst = 5
 
-		command, ok := commands[inputWords[0]]
-		if !ok {
-			fmt.Println("Unknown command")
-			continue
-		}
-		command.callback(&config, cache, inputWords, &pokedex)
-	}
-	if err := scanner.Err(); err != nil { // if err occured during scanning
-		fmt.Fprintln(os.Stderr, "shouldn't see an error scanning a string")
-	}
-}
+// in-memory cache budget, a single pokemon response is a few hundred KB
+const (
+	cacheMaxBytes   = 64 << 20
+	cacheMaxEntries = 500
+)
 
-func programStartingREPL(scanner *bufio.Scanner) bool {
-	fmt.Print("Pokedex > ")
-	return scanner.Scan() // scan based on the rules of "scanner": read a line
+// cachePolicies keep what rarely changes for longer than the 5s every other
+// response gets: the data of a Pokemon or an area only changes when PokeAPI
+// fixes it, and the area list only grows with a new game.
+var cachePolicies = []pokecache.TTLPolicy{
+	{Pattern: pokeapi.ListPattern("location-area"), TTL: 30 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("location-area"), TTL: 7 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("pokemon"), TTL: 7 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("pokemon-species"), TTL: 7 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("evolution-chain"), TTL: 7 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("move"), TTL: 7 * 24 * time.Hour},
+	{Pattern: pokeapi.ResourcePattern("type"), TTL: 7 * 24 * time.Hour},
 }
 
-func cleanInput(text string) []string {
-	var result []string
+// cacheMaxStale is how long an expired response with an ETag or a
+// Last-Modified date is kept, so asking for it again costs a 304 instead
+// of the whole body.
+const cacheMaxStale = 24 * time.Hour
 
-	var temp string
-	temp = ""
-	for i, c := range text {
-		if string(c) == " " {
-			if temp != "" {
-				result = append(result, strings.ToLower(temp))
-				temp = ""
+// newCache returns a cache that also persists entries under the user's
+// cache directory when persistent is set, so responses survive between
+// sessions. It falls back to memory only when that directory is unusable.
+func newCache(duration time.Duration, persistent bool) *pokecache.Cache {
+	options := pokecache.Options{
+		Duration:   duration,
+		MaxBytes:   cacheMaxBytes,
+		MaxEntries: cacheMaxEntries,
+		MaxStale:   cacheMaxStale,
+		Policies:   cachePolicies,
+	}
+	if persistent {
+		dir, err := pokecache.DefaultDir()
+		if err == nil {
+			options.Dir = dir
+			var cache *pokecache.Cache
+			cache, err = pokecache.NewCacheWithOptions(options)
+			if err == nil {
+				return cache
 			}
-			continue
 		}
+		fmt.Fprintln(os.Stderr, "disk cache unavailable, using memory only: " + err.Error())
+		options.Dir = ""
+	}
+	cache, _ := pokecache.NewCacheWithOptions(options) // can't fail without a Dir, cachePolicies are tested
+	return cache
+}
 
-		temp = temp + string(c)
+// newTransport picks where requests go: the network, retried and throttled
+// as retry says, an offline bundle or a recorded session, optionally
+// recording everything to recordPath. The returned function closes the
+// recording.
+func newTransport(offline bool, bundleDir, recordPath, replayPath string, retry pokeapi.RetryOptions) (http.RoundTripper, func() error, error) {
+	noop := func() error { return nil }
+	var transport http.RoundTripper = pokeapi.NewRetryTransport(http.DefaultTransport, retry)
 
-		if i + 1 == len(text) {
-			if temp != "" {
-				result = append(result, strings.ToLower(temp))
-				temp = ""
+	switch {
+	case offline && replayPath != "":
+		return nil, nil, fmt.Errorf("--offline and --replay can't be combined")
+	case replayPath != "":
+		replay, err := pokeapi.LoadReplayTransport(replayPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading session %s: %w", replayPath, err)
+		}
+		transport = replay
+	case offline:
+		if bundleDir == "" {
+			dir, err := dataDir()
+			if err != nil {
+				return nil, nil, fmt.Errorf("no --bundle given and no data directory: %w", err)
 			}
-			continue
+			bundleDir = filepath.Join(dir, "bundle")
 		}
+		transport = &pokeapi.OfflineTransport{Dir: bundleDir}
 	}
 
-	return result
-}
\ No newline at end of file
+	if recordPath == "" {
+		return transport, noop, nil
+	}
+	recording, err := os.Create(recordPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating session log: %w", err)
+	}
+	return pokeapi.NewRecordingTransport(transport, recording), recording.Close, nil
+}
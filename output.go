@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type outputFormat string
+
+const (
+	outputText outputFormat = "text"
+	outputJSON outputFormat = "json"
+	outputYAML outputFormat = "yaml"
+	outputCSV  outputFormat = "csv"
+)
+
+// structured reports whether results are printed as records, the zero
+// value counts as text.
+func (f outputFormat) structured() bool {
+	return f != "" && f != outputText
+}
+
+func parseOutputFormat(name string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(name)); format {
+	case outputText, outputJSON, outputYAML, outputCSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q, expected text, json, yaml or csv", name)
+}
+
+// Record is one structured result. Fields keep the order they were added
+// in, and a value is a string, an int, a []string or a nested Record.
+type Record []Field
+
+type Field struct {
+	Key   string
+	Value any
+}
+
+// printRecords writes a list of records to w in format. Text output is
+// not handled here, every command prints its own.
+func printRecords(w io.Writer, format outputFormat, records []Record) error {
+	switch format {
+	case outputJSON:
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case outputYAML:
+		if len(records) == 0 {
+			_, err := fmt.Fprintln(w, "[]")
+			return err
+		}
+		var buf bytes.Buffer
+		for _, record := range records {
+			writeYAMLRecord(&buf, record, 0, "- ")
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	case outputCSV:
+		return writeCSV(w, records)
+	}
+	return fmt.Errorf("records can't be printed as %s", format)
+}
+
+// printRecord writes a single record, as an object rather than a list
+// where the format makes that distinction.
+func printRecord(w io.Writer, format outputFormat, record Record) error {
+	switch format {
+	case outputJSON:
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case outputYAML:
+		var buf bytes.Buffer
+		writeYAMLRecord(&buf, record, 0, "")
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	return printRecords(w, format, []Record{record})
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeYAMLRecord writes record as a block mapping. firstPrefix replaces
+// the indentation of the first line, which is how list items get their
+// "- " marker.
+func writeYAMLRecord(buf *bytes.Buffer, record Record, indent int, firstPrefix string) {
+	padding := strings.Repeat(" ", indent)
+	if len(record) == 0 {
+		buf.WriteString(padding + firstPrefix + "{}\n")
+		return
+	}
+	for i, field := range record {
+		linePrefix := padding
+		if i == 0 && firstPrefix != "" {
+			linePrefix = padding + firstPrefix
+		} else if firstPrefix != "" {
+			linePrefix = padding + strings.Repeat(" ", len(firstPrefix))
+		}
+		childIndent := len(linePrefix) + 2
+		buf.WriteString(linePrefix + yamlScalar(field.Key) + ":")
+
+		switch value := field.Value.(type) {
+		case Record:
+			if len(value) == 0 {
+				buf.WriteString(" {}\n")
+				continue
+			}
+			buf.WriteString("\n")
+			writeYAMLRecord(buf, value, childIndent, "")
+		case []string:
+			if len(value) == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteString("\n")
+			for _, item := range value {
+				buf.WriteString(strings.Repeat(" ", childIndent) + "- " + yamlScalar(item) + "\n")
+			}
+		case []Record:
+			if len(value) == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteString("\n")
+			for _, item := range value {
+				writeYAMLRecord(buf, item, childIndent, "- ")
+			}
+		default:
+			buf.WriteString(" " + yamlScalar(value) + "\n")
+		}
+	}
+}
+
+// yamlScalar quotes a value only when a plain scalar would be read back
+// as something else.
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		if needsYAMLQuotes(v) {
+			quoted, _ := json.Marshal(v) // a JSON string is a valid double quoted YAML scalar
+			return string(quoted)
+		}
+		return v
+	}
+	return yamlScalar(fmt.Sprint(value))
+}
+
+func needsYAMLQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t")
+}
+
+// writeCSV uses the keys of the first record as the header. Lists are
+// joined with ";" and nested records become key=value pairs.
+func writeCSV(w io.Writer, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	writer := csv.NewWriter(w)
+	header := make([]string, len(records[0]))
+	for i, field := range records[0] {
+		header[i] = field.Key
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, record := range records {
+		row := make([]string, len(record))
+		for i, field := range record {
+			row[i] = csvValue(field.Value)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ";")
+	case Record:
+		pairs := make([]string, len(v))
+		for i, field := range v {
+			pairs[i] = field.Key + "=" + csvValue(field.Value)
+		}
+		return strings.Join(pairs, ";")
+	case []Record:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = csvValue(item)
+		}
+		return strings.Join(items, "|")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func testRecords() []Record {
+	return []Record{
+		{
+			{"name", "pikachu"},
+			{"weight", 60},
+			{"stats", Record{{"hp", 35}, {"special-attack", 50}}},
+			{"types", []string{"electric"}},
+		},
+		{
+			{"name", "no: really"},
+			{"weight", 0},
+			{"stats", Record{}},
+			{"types", []string{}},
+		},
+	}
+}
+
+func TestPrintRecordsJSONKeepsOrder(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printRecords(&buf, outputJSON, testRecords()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid json: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 || decoded[0]["name"] != "pikachu" {
+		t.Errorf("unexpected records: %v", decoded)
+	}
+	compact := &bytes.Buffer{}
+	json.Compact(compact, buf.Bytes())
+	expected := `[{"name":"pikachu","weight":60,"stats":{"hp":35,"special-attack":50},"types":["electric"]},` +
+		`{"name":"no: really","weight":0,"stats":{},"types":[]}]`
+	if compact.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, compact.String())
+	}
+}
+
+func TestPrintRecordsYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printRecords(&buf, outputYAML, testRecords()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `- name: pikachu
+  weight: 60
+  stats:
+    hp: 35
+    special-attack: 50
+  types:
+    - electric
+- name: "no: really"
+  weight: 0
+  stats: {}
+  types: []
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	printRecord(&buf, outputYAML, Record{{"name", "true"}, {"height", 4}})
+	if buf.String() != "name: \"true\"\nheight: 4\n" {
+		t.Errorf("unexpected single record yaml:\n%s", buf.String())
+	}
+}
+
+func TestPrintRecordsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printRecords(&buf, outputCSV, testRecords()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "name,weight,stats,types\n" +
+		"pikachu,60,hp=35;special-attack=50,electric\n" +
+		"no: really,0,,\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, name := range []string{"text", "JSON", "yaml", "csv"} {
+		if _, err := parseOutputFormat(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := parseOutputFormat("xml"); err == nil {
+		t.Errorf("expected xml to be rejected")
+	}
+}
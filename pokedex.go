@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sort"
+)
+
+// Pokedex records which species the player has seen and caught. The
+// Pokemon they own are kept in Storage.
+type Pokedex struct {
+	Entries map[string]PokedexEntry `json:"entries"`
+}
+
+type PokedexEntry struct {
+	Seen   int `json:"seen"`   // times encountered
+	Caught int `json:"caught"` // times caught, released ones included
+}
+
+func NewPokedex() Pokedex {
+	return Pokedex{
+		Entries: make(map[string]PokedexEntry),
+	}
+}
+
+func (p *Pokedex) MarkSeen(name string) {
+	entry := p.Entries[name]
+	entry.Seen++
+	p.Entries[name] = entry
+}
+
+// MarkCaught also counts as a sighting for species caught without an
+// encounter, such as ones migrated from old saves.
+func (p *Pokedex) MarkCaught(name string) {
+	entry := p.Entries[name]
+	entry.Caught++
+	entry.Seen = max(entry.Seen, entry.Caught)
+	p.Entries[name] = entry
+}
+
+func (p *Pokedex) HasCaught(name string) bool {
+	return p.Entries[name].Caught > 0
+}
+
+// Names returns every species in the Pokedex, sorted.
+func (p *Pokedex) Names() []string {
+	names := make([]string, 0, len(p.Entries))
+	for name := range p.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CaughtCount is how many different species have been caught.
+func (p *Pokedex) CaughtCount() int {
+	count := 0
+	for _, entry := range p.Entries {
+		if entry.Caught > 0 {
+			count++
+		}
+	}
+	return count
+}
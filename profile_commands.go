@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// profileArguments is how many arguments each profile subcommand takes.
+var profileArguments = map[string]int{"list": 0, "new": 1, "switch": 1, "delete": 1}
+
+func commandProfile(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if config.Profiles == nil {
+		return reportFailure(errors.New("profiles are not available in this session"))
+	}
+	if len(commandWords) < 2 {
+		return usageError("Usage: profile new|switch|delete <name>, profile list")
+	}
+
+	subcommand, args := commandWords[1], commandWords[2:]
+	expected, ok := profileArguments[subcommand]
+	if !ok {
+		return usageError("Unknown profile command " + subcommand + ", expected new, switch, delete or list")
+	}
+	if len(args) != expected {
+		return usageError("Expected " + strconv.Itoa(expected) + " arguments, but found " + strconv.Itoa(len(args)))
+	}
+
+	switch subcommand {
+	case "list":
+		return profileList(config)
+	case "new":
+		if err := config.Profiles.Create(args[0]); err != nil {
+			return reportFailure(err)
+		}
+		fmt.Println("Created profile " + args[0] + ", use 'profile switch " + args[0] + "' to play as it")
+	case "switch":
+		if !config.Profiles.Exists(args[0]) {
+			return reportFailure(errors.New("there is no profile called " + args[0]))
+		}
+		if args[0] == config.Profiles.Current {
+			fmt.Println("already playing as " + args[0])
+			return nil
+		}
+		if err := autosave(config, pokedex); err != nil {
+			// switching would throw away the progress that wasn't saved
+			return reportFailure(errors.New("still playing as " + config.Profiles.Current + ", its progress couldn't be saved"))
+		}
+		if err := openProfile(config, pokedex, args[0]); err != nil {
+			return err
+		}
+		fmt.Println("Switched to profile " + args[0] + " (" + strconv.Itoa(len(config.Storage.All())) + " Pokemon)")
+	case "delete":
+		if err := config.Profiles.Delete(args[0]); err != nil {
+			return reportFailure(err)
+		}
+		fmt.Println("Deleted profile " + args[0])
+	}
+	return nil
+}
+
+func profileList(config *Config) error {
+	names, err := config.Profiles.List()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Profiles:")
+	for _, name := range names {
+		if name == config.Profiles.Current {
+			fmt.Println(" * " + name)
+		} else {
+			fmt.Println(" - " + name)
+		}
+	}
+	return nil
+}
+
+// openProfile makes name the current profile and replaces the session
+// state with its save, or with a fresh start if it was never saved.
+func openProfile(config *Config, pokedex *Pokedex, name string) error {
+	if err := config.Profiles.SetCurrent(name); err != nil {
+		return err
+	}
+	config.SavePath = config.Profiles.SavePath(name)
+	config.areaMethods = nil
+
+	save, err := loadGame(config.SavePath)
+	if errors.Is(err, os.ErrNotExist) {
+		save = saveFile{Pokedex: NewPokedex(), Storage: NewStorage()}
+	} else if err != nil {
+		// don't let the autosave overwrite a save we couldn't read
+		config.SavePath = ""
+		save = saveFile{Pokedex: NewPokedex(), Storage: NewStorage()}
+		save.apply(config, pokedex)
+		return err
+	}
+	save.apply(config, pokedex)
+	return nil
+}
+
+func commandStats(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 1 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Profiles != nil {
+		fmt.Println("Profile: " + config.Profiles.Current)
+	}
+	fmt.Println("Areas explored: " + strconv.Itoa(config.Stats.AreasExplored))
+	fmt.Println("Catch attempts: " + strconv.Itoa(config.Stats.CatchAttempts))
+	fmt.Println("Caught: " + strconv.Itoa(config.Stats.Caught))
+	fmt.Println("Escaped: " + strconv.Itoa(config.Stats.Escaped))
+	fmt.Println("Species caught: " + strconv.Itoa(pokedex.CaughtCount()))
+	fmt.Println("Pokemon owned: " + strconv.Itoa(len(config.Storage.All())))
+	return nil
+}
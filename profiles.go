@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const defaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Profiles manages the trainer profiles under Dir. Every profile is a
+// directory holding its own save file, and the name of the profile used
+// last is kept in Dir/current.
+type Profiles struct {
+	Dir     string
+	Current string
+}
+
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 lowercase letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// profileFlag turns the --profile value into a profile name, lowercased
+// like input typed at the REPL. It returns "" when no profile was given.
+func profileFlag(value string) (string, error) {
+	name := strings.ToLower(value)
+	if name == "" {
+		return "", nil
+	}
+	return name, validateProfileName(name)
+}
+
+func (p *Profiles) SavePath(name string) string {
+	return filepath.Join(p.Dir, name, "save.json")
+}
+
+func (p *Profiles) Exists(name string) bool {
+	info, err := os.Stat(filepath.Join(p.Dir, name))
+	return err == nil && info.IsDir()
+}
+
+func (p *Profiles) List() ([]string, error) {
+	files, err := os.ReadDir(p.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if file.IsDir() && profileNamePattern.MatchString(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (p *Profiles) Create(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if p.Exists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	return os.MkdirAll(filepath.Join(p.Dir, name), 0o755)
+}
+
+func (p *Profiles) Delete(name string) error {
+	if name == p.Current {
+		return fmt.Errorf("can't delete %q while it is the current profile", name)
+	}
+	if !p.Exists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	return os.RemoveAll(filepath.Join(p.Dir, name))
+}
+
+// SetCurrent makes name the current profile, including for the next session.
+func (p *Profiles) SetCurrent(name string) error {
+	if err := writeFileAtomic(filepath.Join(p.Dir, "current"), []byte(name+"\n")); err != nil {
+		return err
+	}
+	p.Current = name
+	return nil
+}
+
+// openProfiles finds the profile to start with under dataDir/profiles: the
+// requested one if any (created when missing), otherwise the one used last.
+// A save file from before profiles existed becomes the default profile.
+func openProfiles(dataDir string, requested string) (*Profiles, error) {
+	profiles := &Profiles{Dir: filepath.Join(dataDir, "profiles")}
+
+	legacySave := filepath.Join(dataDir, "save.json")
+	if _, err := os.Stat(legacySave); err == nil && !profiles.Exists(defaultProfile) {
+		if err := os.MkdirAll(filepath.Join(profiles.Dir, defaultProfile), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(legacySave, profiles.SavePath(defaultProfile)); err != nil {
+			return nil, err
+		}
+	}
+
+	name := requested
+	if name == "" {
+		name = defaultProfile
+		if current, err := os.ReadFile(filepath.Join(profiles.Dir, "current")); err == nil {
+			if trimmed := strings.TrimSpace(string(current)); profiles.Exists(trimmed) {
+				name = trimmed
+			}
+		}
+	}
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+	if !profiles.Exists(name) {
+		if err := profiles.Create(name); err != nil {
+			return nil, err
+		}
+	}
+	if err := profiles.SetCurrent(name); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenProfilesRemembersCurrent(t *testing.T) {
+	dir := t.TempDir()
+	profiles, err := openProfiles(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profiles.Current != defaultProfile {
+		t.Errorf("expected %q, got %q", defaultProfile, profiles.Current)
+	}
+
+	if _, err := openProfiles(dir, "misty"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	profiles, err = openProfiles(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if profiles.Current != "misty" {
+		t.Errorf("expected the last used profile misty, got %q", profiles.Current)
+	}
+	names, _ := profiles.List()
+	if len(names) != 2 || names[0] != defaultProfile || names[1] != "misty" {
+		t.Errorf("unexpected profiles: %v", names)
+	}
+
+	if _, err := openProfiles(dir, "../escape"); err == nil {
+		t.Errorf("expected an invalid name to be rejected")
+	}
+}
+
+func TestOpenProfilesAdoptsLegacySave(t *testing.T) {
+	dir := t.TempDir()
+	config := newTestConfig()
+	pokedex := NewPokedex()
+	config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", CaughtAt: time.Now()})
+	if err := saveGame(filepath.Join(dir, "save.json"), config, &pokedex); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profiles, err := openProfiles(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "save.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the legacy save to be moved")
+	}
+	save, err := loadGame(profiles.SavePath(defaultProfile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := save.Storage.Find("pikachu"); err != nil {
+		t.Errorf("expected the default profile to own the legacy save's pokemon: %v", err)
+	}
+}
+
+func TestSwitchKeepsUnsavedProfile(t *testing.T) {
+	dir := t.TempDir()
+	config := newTestConfig()
+	pokedex := NewPokedex()
+	var err error
+	config.Profiles, err = openProfiles(dir, "ash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := openProfile(config, &pokedex, "ash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.Profiles.Create("brock")
+	config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", CaughtAt: time.Now()})
+	// a file where the save's directory should be makes saving fail
+	blocker := filepath.Join(dir, "blocker")
+	os.WriteFile(blocker, nil, 0o644)
+	config.SavePath = filepath.Join(blocker, "save.json")
+
+	if err := commandProfile(context.Background(), config, []string{"profile", "switch", "brock"}, &pokedex); exitCodeFor(err) != exitCommandFailed {
+		t.Errorf("expected the switch to fail, got %v", err)
+	}
+	if _, err := config.Storage.Find("pikachu"); err != nil || config.Profiles.Current != "ash" {
+		t.Errorf("expected to still play as ash with pikachu, got %q", config.Profiles.Current)
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	cases := map[string]string{"": "", "Ash": "ash", "MISTY_2": "misty_2"}
+	for value, expected := range cases {
+		if name, err := profileFlag(value); err != nil || name != expected {
+			t.Errorf("%q: expected %q, got %q (%v)", value, expected, name, err)
+		}
+	}
+	for _, value := range []string{"ash ketchum", "../ash", "-ash"} {
+		if _, err := profileFlag(value); err == nil {
+			t.Errorf("%q: expected an invalid profile name", value)
+		}
+	}
+}
+
+func TestProfilesKeepSeparateState(t *testing.T) {
+	dir := t.TempDir()
+	config := newTestConfig()
+	pokedex := NewPokedex()
+	var err error
+	config.Profiles, err = openProfiles(dir, "ash")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := openProfile(config, &pokedex, "ash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", CaughtAt: time.Now()})
+	pokedex.MarkCaught("pikachu")
+	config.Stats.Caught = 1
+
+	commandProfile(context.Background(), config, []string{"profile", "new", "brock"}, &pokedex)
+	commandProfile(context.Background(), config, []string{"profile", "switch", "brock"}, &pokedex)
+	if config.Profiles.Current != "brock" || len(config.Storage.All()) != 0 || pokedex.HasCaught("pikachu") || config.Stats.Caught != 0 {
+		t.Fatalf("expected a fresh brock, got %q with %d pokemon", config.Profiles.Current, len(config.Storage.All()))
+	}
+	if config.Next == nil {
+		t.Errorf("expected a fresh profile to start map on the first page")
+	}
+
+	commandProfile(context.Background(), config, []string{"profile", "switch", "ash"}, &pokedex)
+	if _, err := config.Storage.Find("pikachu"); err != nil || !pokedex.HasCaught("pikachu") || config.Stats.Caught != 1 {
+		t.Errorf("expected ash's pikachu and stats back after switching")
+	}
+
+	for _, words := range [][]string{{"profile", "rename", "ash"}, {"profile", "bogus"}} {
+		if err := commandProfile(context.Background(), config, words, &pokedex); exitCodeFor(err) != exitUsage {
+			t.Errorf("%v: expected a usage error, got %v", words, err)
+		}
+	}
+
+	if err := config.Profiles.Delete("ash"); err == nil {
+		t.Errorf("expected deleting the current profile to fail")
+	}
+	if err := config.Profiles.Delete("brock"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if config.Profiles.Exists("brock") {
+		t.Errorf("expected brock to be deleted")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OmarJarbou/pokedexcli/internal/lineedit"
+)
+
+// number of lines kept in the REPL history file
+const historySize = 1000
+
+// process exit codes for one-shot and script runs
+const (
+	exitOK            = 0
+	exitCommandFailed = 1 // a command's callback returned an error
+	exitUsage         = 2 // bad flags or arguments, unknown command or unreadable script
+)
+
+// errExit is returned by the exit command so main can autosave and stop
+// the same way no matter how the command was run.
+var errExit = errors.New("exit requested")
+
+var errUnknownCommand = errors.New("unknown command")
+
+// errUsage is wrapped by the errors of commands given the wrong number of
+// arguments or an argument they don't accept.
+var errUsage = errors.New("usage error")
+
+// reportedError wraps an error whose message was already printed for the
+// user, so it still counts as a failure but isn't printed twice.
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e reportedError) Unwrap() error {
+	return e.err
+}
+
+// runCommand runs one input line split with splitInput. Words are
+// lowercased, except the arguments of commands that keep their case. Ctrl-C
+// while it runs cancels the command's context instead of ending the
+// process, so a stalled request gives the prompt back.
+func runCommand(commands map[string]cliCommand, config *Config, pokedex *Pokedex, typedWords []string) error {
+	command, ok := commands[strings.ToLower(typedWords[0])]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Unknown command")
+		return errUnknownCommand
+	}
+	inputWords := lowercase(typedWords)
+	if command.keepCase {
+		inputWords = append(inputWords[:1], typedWords[1:]...)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return command.callback(ctx, config, inputWords, pokedex)
+}
+
+// printCommandError shows err unless the user has already seen it.
+func printCommandError(err error) {
+	if errors.Is(err, errUnknownCommand) || errors.As(err, &reportedError{}) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+func exitCodeFor(err error) int {
+	switch {
+	case err == nil || errors.Is(err, errExit):
+		return exitOK
+	case errors.Is(err, errUnknownCommand) || errors.Is(err, errUsage):
+		return exitUsage
+	}
+	return exitCommandFailed
+}
+
+func runREPL(commands map[string]cliCommand, config *Config, pokedex *Pokedex) {
+	if lineedit.IsTerminal(os.Stdin.Fd()) {
+		runLineEditorREPL(commands, config, pokedex)
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for programStartingREPL(scanner) {
+		if !runREPLLine(commands, config, pokedex, scanner.Text()) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil { // if err occured during scanning
+		fmt.Fprintln(os.Stderr, "shouldn't see an error scanning a string")
+	}
+}
+
+// runLineEditorREPL is the REPL for interactive terminals, with history,
+// reverse search and tab completion.
+func runLineEditorREPL(commands map[string]cliCommand, config *Config, pokedex *Pokedex) {
+	editor := lineedit.NewEditor(os.Stdin, os.Stdout)
+	if dir, err := dataDir(); err == nil {
+		history, err := lineedit.LoadHistory(filepath.Join(dir, "history"), historySize)
+		if err == nil {
+			editor.History = history
+		} else {
+			fmt.Fprintln(os.Stderr, "history unavailable: " + err.Error())
+		}
+	}
+	editor.Complete = completer(commands, config, pokedex)
+
+	for {
+		// raw mode only while reading, so commands print and get
+		// signals like they would on a normal terminal
+		restore, err := lineedit.MakeRaw(os.Stdin.Fd())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "line editing unavailable: " + err.Error())
+			return
+		}
+		line, err := editor.ReadLine("Pokedex > ")
+		restore()
+
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			return
+		}
+		if !runREPLLine(commands, config, pokedex, line) {
+			return
+		}
+	}
+}
+
+// runREPLLine runs one line typed at the prompt and reports whether the
+// REPL should keep going.
+func runREPLLine(commands map[string]cliCommand, config *Config, pokedex *Pokedex, inputString string) bool {
+	inputWords := splitInput(inputString)
+	if len(inputWords) == 0 {
+		return true
+	}
+
+	err := runCommand(commands, config, pokedex, inputWords)
+	if errors.Is(err, errExit) {
+		return false
+	}
+	if err != nil {
+		printCommandError(err)
+	}
+	return true
+}
+
+// runOneShot runs the command given on the command line, as in
+// `pokedexcli catch pikachu`.
+func runOneShot(commands map[string]cliCommand, config *Config, pokedex *Pokedex, args []string) int {
+	inputWords := splitInput(strings.Join(args, " "))
+	if len(inputWords) == 0 {
+		return exitOK
+	}
+	err := runCommand(commands, config, pokedex, inputWords)
+	if err != nil {
+		printCommandError(err)
+	}
+	return exitCodeFor(err)
+}
+
+func runScriptFile(commands map[string]cliCommand, config *Config, pokedex *Pokedex, path string) int {
+	if path == "-" {
+		return runScript(commands, config, pokedex, os.Stdin, "stdin")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	defer file.Close()
+	return runScript(commands, config, pokedex, file, path)
+}
+
+// runScript runs every line of script as a command, without a prompt.
+// Blank lines and lines starting with # are skipped. It stops at the first
+// command that fails and returns the matching exit code.
+func runScript(commands map[string]cliCommand, config *Config, pokedex *Pokedex, script io.Reader, name string) int {
+	scanner := bufio.NewScanner(script)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := runCommand(commands, config, pokedex, splitInput(line))
+		if errors.Is(err, errExit) {
+			return exitOK
+		}
+		if err != nil {
+			printCommandError(err)
+			fmt.Fprintln(os.Stderr, name+":"+strconv.Itoa(lineNumber)+": command failed: "+line)
+			return exitCodeFor(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "error reading "+name+": "+err.Error())
+		return exitUsage
+	}
+	return exitOK
+}
+
+func programStartingREPL(scanner *bufio.Scanner) bool {
+	fmt.Print("Pokedex > ")
+	return scanner.Scan() // scan based on the rules of "scanner": read a line
+}
+
+func cleanInput(text string) []string {
+	return lowercase(splitInput(text))
+}
+
+func lowercase(words []string) []string {
+	lowered := make([]string, len(words))
+	for i, word := range words {
+		lowered[i] = strings.ToLower(word)
+	}
+	return lowered
+}
+
+// splitInput splits an input line into words, as typed.
+func splitInput(text string) []string {
+	var result []string
+
+	var temp string
+	temp = ""
+	for i, c := range text {
+		if string(c) == " " {
+			if temp != "" {
+				result = append(result, temp)
+				temp = ""
+			}
+			continue
+		}
+
+		temp = temp + string(c)
+
+		if i+1 == len(text) {
+			if temp != "" {
+				result = append(result, temp)
+				temp = ""
+			}
+			continue
+		}
+	}
+
+	return result
+}
@@ -1,8 +1,20 @@
 package main
 
 import (
-	"testing"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
 )
 
 func TestCleanInput(t *testing.T) {
@@ -45,3 +57,175 @@ func TestCleanInput(t *testing.T) {
 		}
 	}
 }
+
+func TestRunScriptExitCodes(t *testing.T) {
+	var ran []string
+	record := func(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+		ran = append(ran, commandWords[0])
+		return nil
+	}
+	commands := map[string]cliCommand{
+		"ok":   {name: "ok", callback: record},
+		"fail": {name: "fail", callback: func(context.Context, *Config, []string, *Pokedex) error { return errors.New("boom") }},
+		"exit": {name: "exit", callback: func(context.Context, *Config, []string, *Pokedex) error { return errExit }},
+	}
+
+	cases := []struct {
+		script   string
+		expected int
+		ran      int
+	}{
+		{script: "ok\n\n# a comment\n  OK  \n", expected: exitOK, ran: 2},
+		{script: "ok\nfail\nok\n", expected: exitCommandFailed, ran: 1},
+		{script: "ok\nnope\nok\n", expected: exitUsage, ran: 1},
+		{script: "ok\nexit\nok\n", expected: exitOK, ran: 1},
+	}
+	for _, c := range cases {
+		ran = nil
+		code := runScript(commands, &Config{}, &Pokedex{}, strings.NewReader(c.script), "test")
+		if code != c.expected {
+			t.Errorf("script %q: expected exit code %d, got %d", c.script, c.expected, code)
+		}
+		if len(ran) != c.ran {
+			t.Errorf("script %q: expected %d commands to run, got %d", c.script, c.ran, len(ran))
+		}
+	}
+}
+
+func TestExitCodeFor(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected int
+	}{
+		{err: nil, expected: exitOK},
+		{err: errExit, expected: exitOK},
+		{err: errUnknownCommand, expected: exitUsage},
+		{err: errors.New("boom"), expected: exitCommandFailed},
+		{err: reportedError{errors.New("not found")}, expected: exitCommandFailed},
+		{err: reportedError{fmt.Errorf("%w: expected 1 argument", errUsage)}, expected: exitUsage},
+	}
+	for _, c := range cases {
+		if code := exitCodeFor(c.err); code != c.expected {
+			t.Errorf("%v: expected exit code %d, got %d", c.err, c.expected, code)
+		}
+	}
+}
+
+// TestCommandFailuresExit runs commands that can't do what was asked: a
+// script stops there and exits non-zero, like a one-shot run would.
+func TestCommandFailuresExit(t *testing.T) {
+	cases := map[string]int{
+		"catch\n":                         exitUsage,
+		"catch pikachu ultra-mega-ball\n": exitUsage,
+		"box 99\n":                        exitUsage,
+		"catch pikachu\n":                 exitCommandFailed, // nowhere yet, nothing encountered
+		"release pikachu\n":               exitCommandFailed,
+		"party\nrun\nparty\n":             exitCommandFailed,
+	}
+	for script, expected := range cases {
+		config := newOfflineConfig(1)
+		pokedex := NewPokedex()
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != expected {
+			t.Errorf("script %q: expected exit code %d, got %d", script, expected, code)
+		}
+	}
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	if code := runOneShot(Commands(config), config, &pokedex, []string{"catch"}); code != exitUsage {
+		t.Errorf("expected a one-shot catch without a pokemon to exit %d, got %d", exitUsage, code)
+	}
+}
+
+func TestFailuresStayOffStdout(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	config := newOfflineConfig(1)
+	config.Output = outputJSON
+	pokedex := NewPokedex()
+	for _, script := range []string{"box 99\n", "catch pikachu\n", "release pikachu\n", "nope\n"} {
+		runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test")
+	}
+	os.Stdout = stdout
+	writer.Close()
+	if printed, _ := io.ReadAll(reader); len(printed) != 0 {
+		t.Errorf("expected nothing on stdout, got %q", printed)
+	}
+}
+
+// TestOneShotEncounterThenCatch runs each command as its own invocation,
+// loading the save before and autosaving after like main does.
+func TestOneShotEncounterThenCatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	oneShot := func(args ...string) int {
+		t.Helper()
+		config := newOfflineConfig(1)
+		config.SavePath = path
+		pokedex := NewPokedex()
+		if save, err := loadGame(path); err == nil {
+			save.apply(config, &pokedex)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		code := runOneShot(Commands(config), config, &pokedex, args)
+		autosave(config, &pokedex)
+		return code
+	}
+
+	for _, args := range [][]string{
+		{"travel", "canalave-city-area"},
+		{"encounter", "old-rod"},
+		{"catch", "magikarp", "master-ball"},
+	} {
+		if code := oneShot(args...); code != exitOK {
+			t.Fatalf("%v: expected exit code %d, got %d", args, exitOK, code)
+		}
+	}
+	save, err := loadGame(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := save.Storage.Find("magikarp"); err != nil || save.Encounter != nil {
+		t.Errorf("expected magikarp caught and the encounter over, got %v and %+v", err, save.Encounter)
+	}
+}
+
+// TestInterruptCancelsCommand presses Ctrl-C while explore waits on a
+// stalled PokeAPI: the command gives up and the process carries on.
+func TestInterruptCancelsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("can't send an interrupt to the process on windows")
+	}
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	config := &Config{
+		Client:  pokeapi.NewClient(pokeapi.Options{BaseURL: server.URL}),
+		Storage: NewStorage(),
+	}
+	pokedex := NewPokedex()
+
+	go func() {
+		<-started
+		process, _ := os.FindProcess(os.Getpid())
+		process.Signal(os.Interrupt)
+	}()
+	done := make(chan int)
+	go func() {
+		done <- runScript(Commands(config), config, &pokedex, strings.NewReader("explore canalave-city-area\n"), "test")
+	}()
+	select {
+	case code := <-done:
+		if code != exitCommandFailed {
+			t.Errorf("expected exit code %d for a cancelled command, got %d", exitCommandFailed, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected Ctrl-C to cancel the stalled command")
+	}
+}
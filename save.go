@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+)
+
+// saveVersion is the version written by this build. Bump it whenever the
+// save format changes and add a migration from the previous version.
+const saveVersion = 8
+
+type saveFile struct {
+	Version    int            `json:"version"`
+	SavedAt    time.Time      `json:"saved_at"`
+	Pokedex    Pokedex        `json:"pokedex"`
+	Pagination Pagination     `json:"pagination"`
+	Stats      Stats          `json:"stats"`
+	Travel     Travel         `json:"travel"`
+	Storage    Storage        `json:"storage"`
+	Encounter  *wildEncounter `json:"encounter"` // nil when there is none
+}
+
+// Pagination is where map and mapb continue from.
+type Pagination struct {
+	Next     *string `json:"next"`
+	Previous *string `json:"previous"`
+}
+
+// a migration upgrades the top level fields of a save from one version to
+// the next, in place
+type migration func(save map[string]json.RawMessage) error
+
+// saveMigrations[v] upgrades a version v save to version v+1
+var saveMigrations = map[int]migration{
+	1: migrateSaveV1,
+	2: migrateSaveV2,
+	3: migrateSaveV3,
+	4: migrateSaveV4,
+	5: migrateSaveV5,
+	6: migrateSaveV6,
+	7: migrateSaveV7,
+}
+
+// version 2 added pagination and stats, the only stat we can recover from
+// a version 1 save is how many Pokemon were caught
+func migrateSaveV1(save map[string]json.RawMessage) error {
+	var pokedex struct {
+		Items map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(save["pokedex"], &pokedex); err != nil {
+		return err
+	}
+	stats, err := json.Marshal(Stats{Caught: len(pokedex.Items)})
+	if err != nil {
+		return err
+	}
+	save["stats"] = stats
+	save["pagination"] = json.RawMessage(`{"next": null, "previous": null}`)
+	return nil
+}
+
+// version 3 added travel, older saves start out nowhere
+func migrateSaveV2(save map[string]json.RawMessage) error {
+	save["travel"] = json.RawMessage(`{"location": "", "history": []}`)
+	return nil
+}
+
+// version 4 moved the caught pokemon, one per species, from the pokedex
+// into the party and PC boxes, oldest catch first, and turned the pokedex
+// into a seen and caught registry
+func migrateSaveV3(save map[string]json.RawMessage) error {
+	var pokedex struct {
+		Items map[string]map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(save["pokedex"], &pokedex); err != nil {
+		return err
+	}
+
+	type item struct {
+		name     string
+		caughtAt time.Time
+		fields   map[string]json.RawMessage
+	}
+	items := make([]item, 0, len(pokedex.Items))
+	for name, fields := range pokedex.Items {
+		var caughtAt time.Time
+		if raw, ok := fields["caught_at"]; ok {
+			if err := json.Unmarshal(raw, &caughtAt); err != nil {
+				return err
+			}
+		}
+		items = append(items, item{name, caughtAt, fields})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].caughtAt.Equal(items[j].caughtAt) {
+			return items[i].caughtAt.Before(items[j].caughtAt)
+		}
+		return items[i].name < items[j].name
+	})
+
+	storage := struct {
+		NextID int                            `json:"next_id"`
+		Party  []map[string]json.RawMessage   `json:"party"`
+		Boxes  [][]map[string]json.RawMessage `json:"boxes"`
+	}{NextID: len(items) + 1, Party: []map[string]json.RawMessage{}}
+	entries := make(map[string]PokedexEntry)
+	for i, it := range items {
+		it.fields["id"] = json.RawMessage(strconv.Itoa(i + 1))
+		if len(storage.Party) < partySize {
+			storage.Party = append(storage.Party, it.fields)
+		} else {
+			box := (i - partySize) / boxSize
+			for len(storage.Boxes) <= box {
+				storage.Boxes = append(storage.Boxes, []map[string]json.RawMessage{})
+			}
+			storage.Boxes[box] = append(storage.Boxes[box], it.fields)
+		}
+		entries[it.name] = PokedexEntry{Seen: 1, Caught: 1}
+	}
+
+	var err error
+	if save["storage"], err = json.Marshal(storage); err != nil {
+		return err
+	}
+	save["pokedex"], err = json.Marshal(Pokedex{Entries: entries})
+	return err
+}
+
+// version 5 gave caught Pokemon a level, experience, individual and effort
+// values and a nature. Older saves don't know the species' growth rate, so
+// everything caught before starts at level 5 growing at the most common
+// rate, with no individual values and a nature that changes nothing.
+func migrateSaveV4(save map[string]json.RawMessage) error {
+	level := 5
+	fields, err := json.Marshal(struct {
+		Level      int               `json:"level"`
+		Experience int               `json:"experience"`
+		GrowthRate battle.GrowthRate `json:"growth_rate"`
+		Nature     string            `json:"nature"`
+		IVs        battle.Stats      `json:"ivs"`
+		EVs        battle.Stats      `json:"evs"`
+	}{level, battle.ExperienceAt(battle.Medium, level), battle.Medium, "hardy", battle.Stats{}, battle.Stats{}})
+	if err != nil {
+		return err
+	}
+	var added map[string]json.RawMessage
+	if err := json.Unmarshal(fields, &added); err != nil {
+		return err
+	}
+	return updateStored(save, func(pokemon map[string]json.RawMessage) {
+		for key, value := range added {
+			pokemon[key] = value
+		}
+	})
+}
+
+// version 6 added friendship, which starts at 70 for most species
+func migrateSaveV5(save map[string]json.RawMessage) error {
+	return updateStored(save, func(pokemon map[string]json.RawMessage) {
+		pokemon["friendship"] = json.RawMessage("70")
+	})
+}
+
+// version 7 saved the wild encounter, older saves have none
+func migrateSaveV6(save map[string]json.RawMessage) error {
+	save["encounter"] = json.RawMessage("null")
+	return nil
+}
+
+// storedFields are the fields of a stored Pokemon from version 8 on.
+var storedFields = []string{"id", "name", "species", "caught_at", "location", "level", "experience",
+	"growth_rate", "nature", "ivs", "evs", "friendship"}
+
+// version 8 stopped saving the PokeAPI data of every caught Pokemon, such
+// as its moves and sprites, and keeps the name of its species instead of
+// the species resource
+func migrateSaveV7(save map[string]json.RawMessage) error {
+	var errs []error
+	err := updateStored(save, func(pokemon map[string]json.RawMessage) {
+		var name string
+		var species struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(pokemon["name"], &name); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if raw, ok := pokemon["species"]; ok {
+			if err := json.Unmarshal(raw, &species); err != nil {
+				errs = append(errs, err)
+				return
+			}
+		}
+		if species.Name == "" { // saves from before version 4 may not have one
+			species.Name = name
+		}
+		kept := make(map[string]json.RawMessage, len(storedFields))
+		for _, field := range storedFields {
+			if value, ok := pokemon[field]; ok {
+				kept[field] = value
+			}
+		}
+		clear(pokemon)
+		for field, value := range kept {
+			pokemon[field] = value
+		}
+		pokemon["species"], _ = json.Marshal(species.Name) // can't fail for a string
+	})
+	return errors.Join(append(errs, err)...)
+}
+
+// updateStored calls update on every Pokemon in the storage of a version
+// 4 or later save.
+func updateStored(save map[string]json.RawMessage, update func(pokemon map[string]json.RawMessage)) error {
+	var storage struct {
+		NextID int                            `json:"next_id"`
+		Party  []map[string]json.RawMessage   `json:"party"`
+		Boxes  [][]map[string]json.RawMessage `json:"boxes"`
+	}
+	if err := json.Unmarshal(save["storage"], &storage); err != nil {
+		return err
+	}
+	for _, pokemon := range storage.Party {
+		update(pokemon)
+	}
+	for _, box := range storage.Boxes {
+		for _, pokemon := range box {
+			update(pokemon)
+		}
+	}
+	var err error
+	save["storage"], err = json.Marshal(storage)
+	return err
+}
+
+// dataDir is where profiles (and the version 1 save file) live.
+func dataDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "pokedexcli"), nil
+}
+
+func saveGame(path string, config *Config, pokedex *Pokedex) error {
+	data, err := json.MarshalIndent(saveFile{
+		Version: saveVersion,
+		SavedAt: time.Now(),
+		Pokedex: *pokedex,
+		Pagination: Pagination{
+			Next:     config.Next,
+			Previous: config.Previous,
+		},
+		Stats:     config.Stats,
+		Travel:    config.Travel,
+		Storage:   config.Storage,
+		Encounter: config.Encounter,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding save file: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing save file: %w", err)
+	}
+	return nil
+}
+
+func loadGame(path string) (saveFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return saveFile{}, err
+	}
+	save, err := decodeSave(data, saveMigrations)
+	if err != nil {
+		return saveFile{}, fmt.Errorf("error reading save file %s: %w", path, err)
+	}
+	return save, nil
+}
+
+// apply replaces the state in config and pokedex with the saved one.
+func (s saveFile) apply(config *Config, pokedex *Pokedex) {
+	*pokedex = s.Pokedex
+	config.Stats = s.Stats
+	config.Travel = s.Travel
+	config.Storage = s.Storage
+	config.Encounter = s.Encounter
+	config.Battle = nil // battles aren't saved, the encounter is still there to fight
+	config.Next = s.Pagination.Next
+	config.Previous = s.Pagination.Previous
+	if config.Next == nil && config.Previous == nil { // never paged, start at the beginning
+		firstPage := config.Client.LocationAreaPageURL(0, 20)
+		config.Next = &firstPage
+	}
+}
+
+// decodeSave runs every migration needed to bring data up to saveVersion
+// before decoding it.
+func decodeSave(data []byte, migrations map[int]migration) (saveFile, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return saveFile{}, err
+	}
+	var version int
+	if err := json.Unmarshal(raw["version"], &version); err != nil {
+		return saveFile{}, errors.New("missing save file version")
+	}
+	if version > saveVersion {
+		return saveFile{}, fmt.Errorf("save file version %d is newer than this pokedex (version %d)", version, saveVersion)
+	}
+
+	for ; version < saveVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return saveFile{}, fmt.Errorf("no migration from save file version %d", version)
+		}
+		if err := migrate(raw); err != nil {
+			return saveFile{}, fmt.Errorf("error migrating save file from version %d: %w", version, err)
+		}
+	}
+	raw["version"] = json.RawMessage(fmt.Sprint(saveVersion))
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return saveFile{}, err
+	}
+	var save saveFile
+	if err := json.Unmarshal(migrated, &save); err != nil {
+		return saveFile{}, err
+	}
+	if save.Pokedex.Entries == nil {
+		save.Pokedex.Entries = make(map[string]PokedexEntry)
+	}
+	save.Storage.normalize()
+	return save, nil
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place, so a crash leaves either the old file or the new one and
+// never a half written one.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once the rename succeeded
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// newTestConfig returns a Config whose client never reaches the network.
+func newTestConfig() *Config {
+	return &Config{
+		Client:  pokeapi.NewClient(pokeapi.Options{BaseURL: "http://pokeapi.invalid/api/v2/"}),
+		Rand:    rand.New(rand.NewSource(1)),
+		Storage: NewStorage(),
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "save.json")
+	config := newTestConfig()
+	next := "http://pokeapi.invalid/api/v2/location-area?offset=40&limit=20"
+	config.Next = &next
+	config.Stats = Stats{CatchAttempts: 3, Caught: 1, Escaped: 2}
+	config.Travel.moveTo("viridian-forest-area", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	pokedex := NewPokedex()
+	pokedex.MarkSeen("pikachu")
+	pokedex.MarkCaught("pikachu")
+	stored, _, _ := config.Storage.Add(CaughtPokemon{Name: "pikachu", Species: "pikachu", CaughtAt: time.Now(), Location: "viridian-forest-area", Level: 12})
+
+	if err := saveGame(path, config, &pokedex); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	save, err := loadGame(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restoredConfig := newTestConfig()
+	var loaded Pokedex
+	save.apply(restoredConfig, &loaded)
+
+	if restoredConfig.Next == nil || *restoredConfig.Next != next || restoredConfig.Previous != nil {
+		t.Errorf("expected pagination to be restored, got next=%v previous=%v", restoredConfig.Next, restoredConfig.Previous)
+	}
+	if restoredConfig.Stats != config.Stats {
+		t.Errorf("expected stats %+v, got %+v", config.Stats, restoredConfig.Stats)
+	}
+
+	if restoredConfig.Travel.Location != "viridian-forest-area" || len(restoredConfig.Travel.History) != 1 {
+		t.Errorf("expected travel to be restored, got %+v", restoredConfig.Travel)
+	}
+
+	if loaded.Entries["pikachu"] != (PokedexEntry{Seen: 1, Caught: 1}) {
+		t.Errorf("unexpected pokedex entry %+v", loaded.Entries["pikachu"])
+	}
+	pikachu, err := restoredConfig.Storage.Find("pikachu")
+	if err != nil {
+		t.Fatalf("expected pikachu in the loaded party: %v", err)
+	}
+	if pikachu.ID != stored.ID || pikachu.Level != 12 || pikachu.Location != "viridian-forest-area" {
+		t.Errorf("unexpected pikachu: id %d, level %d, location %q", pikachu.ID, pikachu.Level, pikachu.Location)
+	}
+	if !pikachu.CaughtAt.Equal(stored.CaughtAt) {
+		t.Errorf("expected catch time %v, got %v", stored.CaughtAt, pikachu.CaughtAt)
+	}
+	if restoredConfig.Storage.NextID != config.Storage.NextID {
+		t.Errorf("expected the next id %d to be kept, got %d", config.Storage.NextID, restoredConfig.Storage.NextID)
+	}
+
+	// the temp file used for the atomic write must be gone
+	files, _ := os.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("expected only the save file, found %d files", len(files))
+	}
+}
+
+// TestSaveLoadMixedCasePath runs save and load like a user would: paths
+// keep their case even though commands and names are lowercased.
+func TestSaveLoadMixedCasePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SaveDir", "Mine.json")
+	config := newTestConfig()
+	pokedex := NewPokedex()
+	pokedex.MarkSeen("pikachu")
+	script := "SAVE " + path + "\nload " + path + "\n"
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the save at %s: %v", path, err)
+	}
+	if _, err := os.Stat(strings.ToLower(path)); err == nil {
+		t.Errorf("expected nothing at the lowercased path")
+	}
+	if pokedex.Entries["pikachu"].Seen != 1 {
+		t.Errorf("expected the pokedex to be loaded back, got %+v", pokedex.Entries)
+	}
+}
+
+func TestSaveKeepsOldFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "save.json")
+	config := newTestConfig()
+	pokedex := NewPokedex()
+	config.Storage.Add(CaughtPokemon{Name: "bulbasaur", Species: "bulbasaur", CaughtAt: time.Now()})
+	if err := saveGame(path, config, &pokedex); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a read-only directory makes creating the temp file fail
+	if err := os.Chmod(dir, 0o500); err != nil {
+		t.Skip("can't make the directory read-only")
+	}
+	defer os.Chmod(dir, 0o700)
+	if f, err := os.CreateTemp(dir, "probe"); err == nil {
+		f.Close()
+		t.Skip("directory is still writable (running as root?)")
+	}
+
+	config.Storage.Add(CaughtPokemon{Name: "charmander", Species: "charmander", CaughtAt: time.Now()})
+	if err := saveGame(path, config, &pokedex); err == nil {
+		t.Fatalf("expected save into a read-only directory to fail")
+	}
+	save, err := loadGame(path)
+	if err != nil {
+		t.Fatalf("expected the old save to still load: %v", err)
+	}
+	if len(save.Storage.All()) != 1 {
+		t.Errorf("expected the old save with 1 pokemon, got %d", len(save.Storage.All()))
+	}
+}
+
+func TestDecodeSaveMigrations(t *testing.T) {
+	// pretend version 1 renamed "dex" to "pokedex", on top of the real
+	// migrations for every later version
+	migrations := map[int]migration{
+		0: func(save map[string]json.RawMessage) error {
+			save["pokedex"] = save["dex"]
+			delete(save, "dex")
+			return nil
+		},
+	}
+	for version, migrate := range saveMigrations {
+		migrations[version] = migrate
+	}
+	old := `{"version": 0, "dex": {"items": {"eevee": {"name": "eevee", "location": "route-1"}}}}`
+
+	save, err := decodeSave([]byte(old), migrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if save.Version != saveVersion {
+		t.Errorf("expected version %d after migrating, got %d", saveVersion, save.Version)
+	}
+	if eevee, err := save.Storage.Find("eevee"); err != nil || eevee.Location != "route-1" {
+		t.Errorf("expected migrated eevee, got %+v", save.Storage)
+	}
+}
+
+func TestMigrateSaveV1(t *testing.T) {
+	v1 := `{"version": 1, "saved_at": "2025-01-02T03:04:05Z", "pokedex": {"items": {
+		"pikachu": {"name": "pikachu", "caught_at": "2025-01-01T00:00:00Z"},
+		"eevee": {"name": "eevee", "caught_at": "2025-01-01T00:00:00Z"}
+	}}}`
+
+	save, err := decodeSave([]byte(v1), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if save.Stats.Caught != 2 {
+		t.Errorf("expected 2 caught after migrating, got %d", save.Stats.Caught)
+	}
+
+	// a version 1 save never paged, so map starts from the first page
+	config := newTestConfig()
+	var pokedex Pokedex
+	save.apply(config, &pokedex)
+	if config.Next == nil || *config.Next != config.Client.LocationAreaPageURL(0, 20) {
+		t.Errorf("expected map to start on the first page, got %v", config.Next)
+	}
+	if len(config.Storage.All()) != 2 || pokedex.CaughtCount() != 2 {
+		t.Errorf("expected 2 pokemon, got %d", len(config.Storage.All()))
+	}
+}
+
+func TestMigrateSaveV2(t *testing.T) {
+	v2 := `{"version": 2, "pokedex": {"items": {}}, "pagination": {"next": null, "previous": null},
+		"stats": {"catch_attempts": 4, "caught": 1, "escaped": 3, "areas_explored": 2}}`
+
+	save, err := decodeSave([]byte(v2), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if save.Travel.Location != "" || len(save.Travel.History) != 0 {
+		t.Errorf("expected a version 2 save to start nowhere, got %+v", save.Travel)
+	}
+	if save.Stats.CatchAttempts != 4 {
+		t.Errorf("expected stats to survive the migration, got %+v", save.Stats)
+	}
+}
+
+func TestMigrateSaveV3(t *testing.T) {
+	items := []string{}
+	for i := range partySize + 2 {
+		caughtAt := time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC).Format(time.RFC3339)
+		name := fmt.Sprintf("pokemon-%d", partySize+2-i) // names sort the other way round
+		items = append(items, fmt.Sprintf(`%q: {"name": %q, "caught_at": %q, "location": "route-1"}`, name, name, caughtAt))
+	}
+	v3 := `{"version": 3, "pokedex": {"items": {` + strings.Join(items, ",") + `}},
+		"pagination": {"next": null, "previous": null}, "stats": {"caught": 8},
+		"travel": {"location": "route-1", "history": []}}`
+
+	save, err := decodeSave([]byte(v3), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	storage := save.Storage
+	if len(storage.Party) != partySize || len(storage.Boxes[0]) != 2 {
+		t.Fatalf("expected a full party and 2 pokemon in box 1, got %d and %d", len(storage.Party), len(storage.Boxes[0]))
+	}
+	// the oldest catch leads the party and gets the first id
+	if storage.Party[0].Name != "pokemon-8" || storage.Party[0].ID != 1 || storage.Boxes[0][1].ID != 8 {
+		t.Errorf("expected ids in catch order, got %+v", storage.Party[0])
+	}
+	if storage.NextID != 9 {
+		t.Errorf("expected the next id to be 9, got %d", storage.NextID)
+	}
+	if save.Pokedex.CaughtCount() != 8 || save.Pokedex.Entries["pokemon-1"].Seen != 1 {
+		t.Errorf("expected 8 caught species, got %+v", save.Pokedex.Entries)
+	}
+}
+
+func TestMigrateSaveV4(t *testing.T) {
+	v4 := `{"version": 4, "pokedex": {"entries": {"pikachu": {"seen": 1, "caught": 1}}},
+		"pagination": {"next": null, "previous": null}, "stats": {"caught": 1},
+		"travel": {"location": "", "history": []},
+		"storage": {"next_id": 3, "party": [{"id": 1, "name": "pikachu"}], "boxes": [[{"id": 2, "name": "eevee"}]]}}`
+
+	save, err := decodeSave([]byte(v4), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, pokemon := range save.Storage.All() {
+		if pokemon.Level != 5 || pokemon.Experience != 125 || pokemon.GrowthRate != battle.Medium || pokemon.Nature != "hardy" {
+			t.Errorf("expected %s at level 5 with 125 experience, got %+v", pokemon.Name, pokemon)
+		}
+	}
+	if save.Storage.NextID != 3 || save.Storage.Boxes[0][0].ID != 2 {
+		t.Errorf("expected the ids to be kept, got %+v", save.Storage)
+	}
+}
+
+func TestMigrateSaveV5(t *testing.T) {
+	v5 := `{"version": 5, "pokedex": {"entries": {}}, "stats": {}, "travel": {"location": "", "history": []},
+		"storage": {"next_id": 2, "party": [{"id": 1, "name": "pikachu", "level": 12}], "boxes": []}}`
+
+	save, err := decodeSave([]byte(v5), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pikachu := save.Storage.Party[0]; pikachu.Friendship != 70 || pikachu.Level != 12 {
+		t.Errorf("expected pikachu to start with 70 friendship, got %+v", pikachu)
+	}
+}
+
+func TestMigrateSaveV6(t *testing.T) {
+	v6 := `{"version": 6, "pokedex": {"entries": {}}, "stats": {}, "travel": {"location": "route-1", "history": []},
+		"storage": {"next_id": 1, "party": [], "boxes": []}}`
+
+	save, err := decodeSave([]byte(v6), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if save.Encounter != nil {
+		t.Errorf("expected a version 6 save to have no encounter, got %+v", save.Encounter)
+	}
+}
+
+func TestMigrateSaveV7(t *testing.T) {
+	v7 := `{"version": 7, "pokedex": {"entries": {}}, "stats": {}, "travel": {"location": "", "history": []},
+		"encounter": null, "storage": {"next_id": 3, "boxes": [], "party": [
+			{"id": 1, "name": "pikachu", "level": 12, "height": 4, "weight": 60,
+				"species": {"name": "pikachu", "url": "https://pokeapi.co/api/v2/pokemon-species/25/"},
+				"moves": [{"move": {"name": "thunder-shock"}}], "sprites": {"front_default": "pikachu.png"}},
+			{"id": 2, "name": "eevee", "level": 5}
+		]}}`
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(v7), &raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := migrateSaveV7(raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, field := range []string{"moves", "sprites", "weight", "url"} {
+		if strings.Contains(string(raw["storage"]), field) {
+			t.Errorf("expected %s to be dropped, got %s", field, raw["storage"])
+		}
+	}
+
+	save, err := decodeSave([]byte(v7), saveMigrations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pikachu, eevee := save.Storage.Party[0], save.Storage.Party[1]
+	if pikachu.Species != "pikachu" || pikachu.Level != 12 || eevee.Species != "eevee" {
+		t.Errorf("expected the species names to be kept, got %+v and %+v", pikachu, eevee)
+	}
+}
+
+func TestDecodeSaveRejects(t *testing.T) {
+	cases := map[string]string{
+		"newer version":     `{"version": 999, "pokedex": {}}`,
+		"missing version":   `{"pokedex": {}}`,
+		"missing migration": `{"version": -1, "pokedex": {}}`,
+		"not json":          `{"version": 1, "pokedex"`,
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeSave([]byte(data), saveMigrations); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+	if _, err := decodeSave([]byte(`{"version": 999}`), saveMigrations); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a newer version error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OmarJarbou/pokedexcli/internal/pokeapi"
+)
+
+// newOfflineConfig returns a Config served from the bundle in testdata, so
+// whole sessions can run without the network.
+func newOfflineConfig(seed int64) *Config {
+	client := pokeapi.NewClient(pokeapi.Options{
+		HTTPClient: &http.Client{Transport: &pokeapi.OfflineTransport{Dir: "testdata/bundle"}},
+	})
+	firstPage := client.LocationAreaPageURL(0, 20)
+	return &Config{
+		Next:    &firstPage,
+		Client:  client,
+		Rand:    rand.New(rand.NewSource(seed)),
+		Storage: NewStorage(),
+	}
+}
+
+func TestOfflineSession(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	script := `
+map
+travel eterna-forest-area
+explore
+encounter
+`
+	code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if config.Encounter == nil {
+		t.Fatalf("expected a wild pokemon after encounter")
+	}
+	wild := config.Encounter.Pokemon
+	code = runScript(Commands(config), config, &pokedex, strings.NewReader("catch "+wild+" master-ball\n"), "test")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if _, err := config.Storage.Find(wild); err != nil || !pokedex.HasCaught(wild) {
+		t.Errorf("expected %s to be caught: %v", wild, err)
+	}
+	if config.Stats.AreasExplored != 1 || config.Stats.Caught != 1 {
+		t.Errorf("unexpected stats %+v", config.Stats)
+	}
+
+	// anything outside the bundle fails cleanly instead of reaching the network
+	code = runScript(Commands(config), config, &pokedex, strings.NewReader("explore pastoria-city-area\n"), "test")
+	if code != exitCommandFailed {
+		t.Errorf("expected exit code %d for a bundle miss, got %d", exitCommandFailed, code)
+	}
+}
+
+// TestReplaySession replays a session recorded with --record, so the
+// commands are checked against the exact responses they saw back then.
+func TestReplaySession(t *testing.T) {
+	replay, err := pokeapi.LoadReplayTransport("testdata/sessions/canalave.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config := newOfflineConfig(1)
+	config.Client = pokeapi.NewClient(pokeapi.Options{
+		HTTPClient: &http.Client{Transport: replay},
+	})
+	pokedex := NewPokedex()
+	script := `
+map
+travel canalave-city-area
+explore
+encounter old-rod
+catch magikarp master-ball
+inspect magikarp
+`
+	code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	caught, err := config.Storage.Find("magikarp")
+	if err != nil || caught.Location != "canalave-city-area" {
+		t.Errorf("expected magikarp caught in canalave-city-area, got %+v", caught)
+	}
+
+	code = runScript(Commands(config), config, &pokedex, strings.NewReader("explore eterna-forest-area\n"), "test")
+	if code != exitCommandFailed {
+		t.Errorf("expected exit code %d for a request outside the session, got %d", exitCommandFailed, code)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OmarJarbou/pokedexcli/internal/battle"
+)
+
+const (
+	partySize = 6
+	boxCount  = 8
+	boxSize   = 30
+)
+
+var errStorageFull = errors.New("your party and every PC box are full, release a pokemon first")
+
+// CaughtPokemon is one Pokemon the player owns. ID tells apart several of
+// the same species and never changes or gets reused. Only what belongs to
+// this one Pokemon is kept, the data every pikachu shares is looked up by
+// Name through the cache when it's needed.
+type CaughtPokemon struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`    // the PokeAPI pokemon, such as pikachu
+	Species    string            `json:"species"` // its species, usually named the same
+	CaughtAt   time.Time         `json:"caught_at"`
+	Location   string            `json:"location,omitempty"`
+	Level      int               `json:"level"`
+	Experience int               `json:"experience"`
+	GrowthRate battle.GrowthRate `json:"growth_rate"`
+	Nature     string            `json:"nature"`
+	IVs        battle.Stats      `json:"ivs"`
+	EVs        battle.Stats      `json:"evs"`
+	Friendship int               `json:"friendship"`
+}
+
+// Storage holds the party that travels with the player and the numbered
+// PC boxes for everything else.
+type Storage struct {
+	NextID int               `json:"next_id"`
+	Party  []CaughtPokemon   `json:"party"`
+	Boxes  [][]CaughtPokemon `json:"boxes"`
+}
+
+func NewStorage() Storage {
+	s := Storage{NextID: 1}
+	s.normalize()
+	return s
+}
+
+// normalize fills in what an empty or migrated save leaves out.
+func (s *Storage) normalize() {
+	if s.NextID < 1 {
+		s.NextID = 1
+	}
+	if s.Party == nil {
+		s.Party = []CaughtPokemon{}
+	}
+	for len(s.Boxes) < boxCount {
+		s.Boxes = append(s.Boxes, []CaughtPokemon{})
+	}
+	for i := range s.Boxes {
+		if s.Boxes[i] == nil {
+			s.Boxes[i] = []CaughtPokemon{}
+		}
+	}
+}
+
+// storageSlot is where a Pokemon is kept: box 0 is the party, 1 to
+// boxCount the PC boxes.
+type storageSlot struct {
+	box   int
+	index int
+}
+
+func (s *Storage) list(box int) *[]CaughtPokemon {
+	if box == 0 {
+		return &s.Party
+	}
+	return &s.Boxes[box-1]
+}
+
+func capacity(box int) int {
+	if box == 0 {
+		return partySize
+	}
+	return boxSize
+}
+
+func (s *Storage) at(slot storageSlot) *CaughtPokemon {
+	return &(*s.list(slot.box))[slot.index]
+}
+
+// All returns every Pokemon owned, the party first.
+func (s *Storage) All() []CaughtPokemon {
+	all := append([]CaughtPokemon{}, s.Party...)
+	for _, box := range s.Boxes {
+		all = append(all, box...)
+	}
+	return all
+}
+
+// Add stores a newly caught Pokemon in the party, or in the first box with
+// room once the party is full. It returns the Pokemon with its new ID and
+// the box it went to.
+func (s *Storage) Add(caught CaughtPokemon) (CaughtPokemon, int, error) {
+	box := s.firstWithRoom(0)
+	if box < 0 {
+		return CaughtPokemon{}, 0, errStorageFull
+	}
+	caught.ID = s.NextID
+	s.NextID++
+	list := s.list(box)
+	*list = append(*list, caught)
+	return caught, box, nil
+}
+
+// firstWithRoom returns the first box from box on that isn't full, or -1.
+func (s *Storage) firstWithRoom(box int) int {
+	for ; box <= boxCount; box++ {
+		if len(*s.list(box)) < capacity(box) {
+			return box
+		}
+	}
+	return -1
+}
+
+// find looks a Pokemon up by ID ("7" or "#7") or by name, which has to be
+// unambiguous.
+func (s *Storage) find(ref string) (storageSlot, error) {
+	if id, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		for box := 0; box <= boxCount; box++ {
+			for i, pokemon := range *s.list(box) {
+				if pokemon.ID == id {
+					return storageSlot{box, i}, nil
+				}
+			}
+		}
+		return storageSlot{}, fmt.Errorf("you have no pokemon with id %d", id)
+	}
+
+	var found []storageSlot
+	var ids []string
+	for box := 0; box <= boxCount; box++ {
+		for i, pokemon := range *s.list(box) {
+			if pokemon.Name == ref {
+				found = append(found, storageSlot{box, i})
+				ids = append(ids, "#"+strconv.Itoa(pokemon.ID))
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return storageSlot{}, fmt.Errorf("you have no %s", ref)
+	case 1:
+		return found[0], nil
+	}
+	return storageSlot{}, fmt.Errorf("you have %d %s, pick one by id: %s", len(found), ref, strings.Join(ids, ", "))
+}
+
+// Find returns the Pokemon ref names, see find.
+func (s *Storage) Find(ref string) (CaughtPokemon, error) {
+	slot, err := s.find(ref)
+	if err != nil {
+		return CaughtPokemon{}, err
+	}
+	return *s.at(slot), nil
+}
+
+// Get returns the Pokemon with id to be changed in place, nil when there
+// is none.
+func (s *Storage) Get(id int) *CaughtPokemon {
+	slot, err := s.find(strconv.Itoa(id))
+	if err != nil {
+		return nil
+	}
+	return s.at(slot)
+}
+
+// InParty reports whether the Pokemon with id travels with the player.
+func (s *Storage) InParty(id int) bool {
+	for _, pokemon := range s.Party {
+		if pokemon.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) remove(slot storageSlot) CaughtPokemon {
+	list := s.list(slot.box)
+	pokemon := (*list)[slot.index]
+	*list = append((*list)[:slot.index], (*list)[slot.index+1:]...)
+	return pokemon
+}
+
+// anyBox asks Deposit for the first PC box with room.
+const anyBox = -1
+
+// Deposit moves a party Pokemon into box, or the first box with room when
+// box is anyBox. The last Pokemon in the party has to stay.
+func (s *Storage) Deposit(ref string, box int) (int, error) {
+	slot, err := s.find(ref)
+	if err != nil {
+		return 0, err
+	}
+	if slot.box != 0 {
+		return 0, fmt.Errorf("%s is already in box %d", ref, slot.box)
+	}
+	if len(s.Party) == 1 {
+		return 0, errors.New("you can't deposit your last party pokemon")
+	}
+	if box != anyBox && (box < 1 || box > boxCount) {
+		return 0, fmt.Errorf("there is no box %d, boxes go from 1 to %d", box, boxCount)
+	}
+	if box == anyBox {
+		if box = s.firstWithRoom(1); box < 0 {
+			return 0, errors.New("every PC box is full")
+		}
+	} else if len(*s.list(box)) >= boxSize {
+		return 0, fmt.Errorf("box %d is full", box)
+	}
+	pokemon := s.remove(slot)
+	list := s.list(box)
+	*list = append(*list, pokemon)
+	return box, nil
+}
+
+// Withdraw moves a Pokemon from its box to the end of the party.
+func (s *Storage) Withdraw(ref string) error {
+	slot, err := s.find(ref)
+	if err != nil {
+		return err
+	}
+	if slot.box == 0 {
+		return fmt.Errorf("%s is already in your party", ref)
+	}
+	if len(s.Party) >= partySize {
+		return errors.New("your party is full, deposit or swap a pokemon first")
+	}
+	s.Party = append(s.Party, s.remove(slot))
+	return nil
+}
+
+// Swap exchanges the places of two Pokemon, which reorders the party or
+// trades a party Pokemon for a boxed one.
+func (s *Storage) Swap(first, second string) error {
+	a, err := s.find(first)
+	if err != nil {
+		return err
+	}
+	b, err := s.find(second)
+	if err != nil {
+		return err
+	}
+	if a == b {
+		return errors.New("pick two different pokemon to swap")
+	}
+	*s.at(a), *s.at(b) = *s.at(b), *s.at(a)
+	return nil
+}
+
+// Release gives a Pokemon up for good. Like Deposit, it keeps the last
+// Pokemon in the party.
+func (s *Storage) Release(ref string) (CaughtPokemon, error) {
+	slot, err := s.find(ref)
+	if err != nil {
+		return CaughtPokemon{}, err
+	}
+	if slot.box == 0 && len(s.Party) == 1 {
+		return CaughtPokemon{}, errors.New("you can't release your last party pokemon")
+	}
+	return s.remove(slot), nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+func commandParty(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 1 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	return printStored(config, "party", config.Storage.Party)
+}
+
+func commandBox(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) > 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 or 1 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	box := 1
+	if len(commandWords) == 2 {
+		n, err := strconv.Atoi(commandWords[1])
+		if err != nil || n < 1 || n > boxCount {
+			return usageError("there is no box " + commandWords[1] + ", boxes go from 1 to " + strconv.Itoa(boxCount))
+		}
+		box = n
+	}
+	return printStored(config, "box "+strconv.Itoa(box), *config.Storage.list(box))
+}
+
+func printStored(config *Config, place string, pokemons []CaughtPokemon) error {
+	if config.Output.structured() {
+		records := make([]Record, 0, len(pokemons))
+		for _, pokemon := range pokemons {
+			records = append(records, Record{
+				{"id", pokemon.ID},
+				{"name", pokemon.Name},
+				{"place", place},
+				{"caught_at", pokemon.CaughtAt.Format(time.RFC3339)},
+				{"location", pokemon.Location},
+			})
+		}
+		return printRecords(os.Stdout, config.Output, records)
+	}
+
+	if len(pokemons) == 0 {
+		fmt.Println("Your " + place + " is empty")
+		return nil
+	}
+	fmt.Println("Your " + place + ":")
+	for _, pokemon := range pokemons {
+		fmt.Println(" #" + strconv.Itoa(pokemon.ID) + " " + pokemon.Name)
+	}
+	return nil
+}
+
+func commandDeposit(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 && len(commandWords) != 3 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 or 2 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	box := anyBox
+	if len(commandWords) == 3 {
+		n, err := strconv.Atoi(commandWords[2])
+		if err != nil || n < 1 || n > boxCount {
+			return usageError("there is no box " + commandWords[2] + ", boxes go from 1 to " + strconv.Itoa(boxCount))
+		}
+		box = n
+	}
+	if err := checkNotBattling(config, commandWords[1]); err != nil {
+		return reportFailure(err)
+	}
+
+	box, err := config.Storage.Deposit(commandWords[1], box)
+	if err != nil {
+		return reportFailure(err)
+	}
+	fmt.Println(commandWords[1] + " was deposited in box " + strconv.Itoa(box))
+	return nil
+}
+
+func commandWithdraw(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	if err := config.Storage.Withdraw(commandWords[1]); err != nil {
+		return reportFailure(err)
+	}
+	fmt.Println(commandWords[1] + " joined your party")
+	return nil
+}
+
+func commandSwap(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 3 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 2 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+	for _, ref := range commandWords[1:] {
+		if err := checkNotBattling(config, ref); err != nil {
+			return reportFailure(err)
+		}
+	}
+	if err := config.Storage.Swap(commandWords[1], commandWords[2]); err != nil {
+		return reportFailure(err)
+	}
+	fmt.Println("Swapped " + commandWords[1] + " and " + commandWords[2])
+	return nil
+}
+
+func commandRelease(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	if err := checkNotBattling(config, commandWords[1]); err != nil {
+		return reportFailure(err)
+	}
+	released, err := config.Storage.Release(commandWords[1])
+	if err != nil {
+		return reportFailure(err)
+	}
+	fmt.Println(released.Name + " (#" + strconv.Itoa(released.ID) + ") was released, bye " + released.Name + "!")
+	return nil
+}
+
+// checkNotBattling keeps the Pokemon that is fighting where it is.
+func checkNotBattling(config *Config, ref string) error {
+	if config.Battle == nil {
+		return nil
+	}
+	pokemon, err := config.Storage.Find(ref)
+	if err == nil && pokemon.ID == config.BattlerID {
+		return fmt.Errorf("%s is in the middle of a battle", pokemon.Name)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStorage holds the named Pokemon, the first partySize in the party.
+func newTestStorage(t *testing.T, names ...string) Storage {
+	t.Helper()
+	storage := NewStorage()
+	for _, name := range names {
+		if _, _, err := storage.Add(newTestCaught(name)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return storage
+}
+
+func newTestCaught(name string) CaughtPokemon {
+	return CaughtPokemon{Name: name, Species: name, CaughtAt: time.Now(), Location: "route-1", Level: 5}
+}
+
+func partyNames(storage Storage) string {
+	return strings.Join(storedNames(storage.Party), ",")
+}
+
+func TestStorageAdd(t *testing.T) {
+	storage := newTestStorage(t, "pikachu", "pikachu", "eevee", "bidoof", "budew", "shellos")
+	if len(storage.Party) != partySize {
+		t.Fatalf("expected a full party, got %d", len(storage.Party))
+	}
+	// two of a species are two pokemon
+	if storage.Party[0].ID == storage.Party[1].ID {
+		t.Errorf("expected unique ids, got %d twice", storage.Party[0].ID)
+	}
+
+	caught, box, err := storage.Add(newTestCaught("magikarp"))
+	if err != nil || box != 1 || caught.ID != 7 {
+		t.Errorf("expected magikarp #7 to go to box 1, got #%d in box %d (%v)", caught.ID, box, err)
+	}
+
+	full := NewStorage()
+	for range partySize + boxCount*boxSize {
+		if _, _, err := full.Add(newTestCaught("magikarp")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, _, err := full.Add(newTestCaught("magikarp")); err != errStorageFull {
+		t.Errorf("expected errStorageFull, got %v", err)
+	}
+}
+
+func TestStorageFind(t *testing.T) {
+	storage := newTestStorage(t, "pikachu", "eevee", "pikachu")
+	if _, err := storage.Find("pikachu"); err == nil || !strings.Contains(err.Error(), "#1, #3") {
+		t.Errorf("expected an ambiguous name to list the ids, got %v", err)
+	}
+	for _, ref := range []string{"3", "#3"} {
+		if pokemon, err := storage.Find(ref); err != nil || pokemon.ID != 3 {
+			t.Errorf("%s: expected pikachu #3, got %+v (%v)", ref, pokemon, err)
+		}
+	}
+	if _, err := storage.Find("mew"); err == nil {
+		t.Errorf("expected an error for a pokemon you don't have")
+	}
+}
+
+func TestStorageMoves(t *testing.T) {
+	storage := newTestStorage(t, "pikachu", "eevee", "bidoof")
+
+	box, err := storage.Deposit("eevee", anyBox)
+	if err != nil || box != 1 || partyNames(storage) != "pikachu,bidoof" {
+		t.Fatalf("expected eevee in box 1, got box %d, party %s (%v)", box, partyNames(storage), err)
+	}
+	if _, err := storage.Deposit("bidoof", 3); err != nil || len(storage.Boxes[2]) != 1 {
+		t.Fatalf("expected bidoof in box 3 (%v)", err)
+	}
+	if _, err := storage.Deposit("pikachu", anyBox); err == nil {
+		t.Errorf("expected the last party pokemon to stay")
+	}
+	if _, err := storage.Release("pikachu"); err == nil {
+		t.Errorf("expected the last party pokemon not to be released")
+	}
+
+	// swapping trades places between the party and a box
+	if err := storage.Swap("pikachu", "eevee"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partyNames(storage) != "eevee" || storage.Boxes[0][0].Name != "pikachu" {
+		t.Errorf("expected eevee and pikachu to trade places, party %s", partyNames(storage))
+	}
+
+	if err := storage.Withdraw("bidoof"); err != nil || partyNames(storage) != "eevee,bidoof" {
+		t.Errorf("expected bidoof back in the party, got %s (%v)", partyNames(storage), err)
+	}
+	if err := storage.Withdraw("bidoof"); err == nil {
+		t.Errorf("expected withdrawing a party pokemon to fail")
+	}
+
+	released, err := storage.Release("pikachu")
+	if err != nil || released.Name != "pikachu" || len(storage.All()) != 2 {
+		t.Errorf("expected pikachu to be released, got %+v (%v)", released, err)
+	}
+	// ids are never reused
+	caught, _, _ := storage.Add(newTestCaught("pikachu"))
+	if caught.ID != 4 {
+		t.Errorf("expected the next id to be 4, got %d", caught.ID)
+	}
+}
+
+func TestCatchUsesClock(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	caughtAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	config.Clock = func() time.Time { return caughtAt }
+	script := `
+travel canalave-city-area
+encounter old-rod
+catch magikarp master-ball
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if len(config.Storage.Party) != 1 || !config.Storage.Party[0].CaughtAt.Equal(caughtAt) {
+		t.Errorf("expected magikarp caught at %v, got party %+v", caughtAt, config.Storage.Party)
+	}
+}
+
+func TestDepositBoxRange(t *testing.T) {
+	storage := newTestStorage(t, "pikachu", "eevee")
+	for _, box := range []int{0, boxCount + 1} {
+		if _, err := storage.Deposit("eevee", box); err == nil {
+			t.Errorf("expected there to be no box %d", box)
+		}
+	}
+	if _, err := storage.Deposit("eevee", boxCount); err != nil || len(storage.Boxes[boxCount-1]) != 1 {
+		t.Errorf("expected eevee in box %d (%v)", boxCount, err)
+	}
+
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	config.Storage.Add(newTestCaught("pikachu"))
+	config.Storage.Add(newTestCaught("eevee"))
+	for _, script := range []string{"deposit eevee 0\n", "deposit eevee 9\n"} {
+		if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitUsage {
+			t.Errorf("%q: expected exit code %d, got %d", script, exitUsage, code)
+		}
+	}
+	if partyNames(config.Storage) != "pikachu,eevee" {
+		t.Errorf("expected eevee to stay in the party, got %s", partyNames(config.Storage))
+	}
+}
+
+func TestCatchingTwiceKeepsBoth(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	script := `
+travel canalave-city-area
+encounter old-rod
+catch magikarp master-ball
+encounter old-rod
+catch magikarp master-ball
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if len(config.Storage.Party) != 2 || pokedex.Entries["magikarp"] != (PokedexEntry{Seen: 2, Caught: 2}) {
+		t.Errorf("expected two magikarp, got party %v and entry %+v", partyNames(config.Storage), pokedex.Entries["magikarp"])
+	}
+
+	code := runScript(Commands(config), config, &pokedex, strings.NewReader("release 1\n"), "test")
+	if code != exitOK || len(config.Storage.Party) != 1 || !pokedex.HasCaught("magikarp") {
+		t.Errorf("expected releasing to keep the pokedex entry, got party %v", partyNames(config.Storage))
+	}
+}
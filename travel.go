@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// whereHistory is how many earlier stops where lists.
+const whereHistory = 5
+
+func commandTravel(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 2 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 1 argument, but found " + strconv.Itoa(foundArguments))
+	}
+	if config.Battle != nil {
+		return reportFailure(errors.New("you can't travel in the middle of a battle, run first"))
+	}
+
+	// fetching the area is how we know it exists
+	locationArea, err := config.Client.GetLocationArea(ctx, commandWords[1])
+	if err != nil {
+		return reportAPIError(err, "there is no location area called " + commandWords[1])
+	}
+	if locationArea.Name == config.Travel.Location {
+		fmt.Println("you are already in " + locationArea.Name)
+		return nil
+	}
+
+	config.Travel.moveTo(locationArea.Name, time.Now())
+	config.Encounter = nil // the wild pokemon stays behind
+	config.areaMethods = encounterMethods(locationArea)
+	config.rememberArea(locationArea.Name)
+	fmt.Println("You travelled to " + locationArea.Name)
+	return nil
+}
+
+func commandWhere(ctx context.Context, config *Config, commandWords []string, pokedex *Pokedex) error {
+	if len(commandWords) != 1 {
+		foundArguments := len(commandWords) - 1
+		return usageError("Expected 0 arguments, but found " + strconv.Itoa(foundArguments))
+	}
+
+	// the current location is the last stop, the ones before it are history
+	history := config.Travel.History
+	if len(history) > 0 && history[len(history)-1].Area == config.Travel.Location {
+		history = history[:len(history)-1]
+	}
+	history = history[max(0, len(history)-whereHistory):]
+
+	if config.Output.structured() {
+		previous := make([]Record, 0, len(history))
+		for i := len(history) - 1; i >= 0; i-- {
+			previous = append(previous, Record{
+				{"area", history[i].Area},
+				{"arrived_at", history[i].ArrivedAt.Format(time.RFC3339)},
+			})
+		}
+		return printRecord(os.Stdout, config.Output, Record{
+			{"location_area", config.Travel.Location},
+			{"previous", previous},
+		})
+	}
+
+	if config.Travel.Location == "" {
+		fmt.Println("You are nowhere yet, use travel to go to a location area")
+		return nil
+	}
+	fmt.Println("You are in " + config.Travel.Location)
+	if len(history) > 0 {
+		fmt.Println("Before that:")
+		for i := len(history) - 1; i >= 0; i-- {
+			fmt.Println(" - " + history[i].Area + " (" + history[i].ArrivedAt.Format("2006-01-02 15:04") + ")")
+		}
+	}
+	return nil
+}
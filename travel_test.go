@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTravel(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	script := `
+travel canalave-city-area
+travel eterna-forest-area
+travel eterna-forest-area
+where
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if config.Travel.Location != "eterna-forest-area" {
+		t.Errorf("expected to be in eterna-forest-area, got %q", config.Travel.Location)
+	}
+	// travelling to where you already are isn't a stop
+	if len(config.Travel.History) != 2 || config.Travel.History[0].Area != "canalave-city-area" {
+		t.Errorf("unexpected history %+v", config.Travel.History)
+	}
+
+	// an area that doesn't exist leaves the player where they were
+	code := runScript(Commands(config), config, &pokedex, strings.NewReader("travel pastoria-city-area\n"), "test")
+	if code != exitCommandFailed || config.Travel.Location != "eterna-forest-area" {
+		t.Errorf("expected a failed travel to keep the location, got exit %d in %q", code, config.Travel.Location)
+	}
+}
+
+func TestCatchOnlyWhatAppearsHere(t *testing.T) {
+	config := newOfflineConfig(1)
+	pokedex := NewPokedex()
+	// magikarp lives in canalave-city-area, not in the forest
+	config.Encounter = &wildEncounter{Pokemon: "magikarp", Level: 5, Method: "old-rod", Area: "canalave-city-area"}
+	script := `
+travel eterna-forest-area
+catch magikarp master-ball
+`
+	if code := runScript(Commands(config), config, &pokedex, strings.NewReader(script), "test"); code != exitCommandFailed {
+		t.Fatalf("expected exit code %d, got %d", exitCommandFailed, code)
+	}
+	if config.Stats.CatchAttempts != 0 || len(config.Storage.All()) != 0 {
+		t.Errorf("expected no throw at a pokemon that can't appear here, got %+v", config.Stats)
+	}
+	if config.Encounter != nil {
+		t.Errorf("expected travelling to end the encounter")
+	}
+}
+
+func TestTravelHistoryIsBounded(t *testing.T) {
+	var travel Travel
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range maxTravelHistory + 10 {
+		travel.moveTo("area-"+string(rune('a'+i%26)), start.Add(time.Duration(i)*time.Minute))
+	}
+	if len(travel.History) != maxTravelHistory {
+		t.Fatalf("expected %d stops, got %d", maxTravelHistory, len(travel.History))
+	}
+	if !travel.History[0].ArrivedAt.Equal(start.Add(10 * time.Minute)) {
+		t.Errorf("expected the oldest stops to be dropped, first is %v", travel.History[0].ArrivedAt)
+	}
+}